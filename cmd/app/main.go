@@ -1,10 +1,8 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,9 +10,14 @@ import (
 	"os"
 	"strconv"
 
+	"transactions-summary/internal/entities"
 	"transactions-summary/internal/infrastructure/database"
 	"transactions-summary/internal/infrastructure/email"
+	"transactions-summary/internal/infrastructure/emailtemplate"
 	"transactions-summary/internal/infrastructure/file"
+	"transactions-summary/internal/infrastructure/statement"
+	"transactions-summary/internal/infrastructure/storage"
+	"transactions-summary/internal/interfaces"
 	"transactions-summary/internal/usecases"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -35,13 +38,13 @@ func getSecrets(ctx context.Context, secretName string, cfg aws.Config) (map[str
 
 	result, err := client.GetSecretValue(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve secret: %v", err)
+		return nil, fmt.Errorf("failed to retrieve secret: %w", err)
 	}
 
 	var secretMap map[string]string
 	err = json.Unmarshal([]byte(*result.SecretString), &secretMap)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal secret string: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal secret string: %w", err)
 	}
 
 	return secretMap, nil
@@ -52,133 +55,223 @@ func handler(ctx context.Context, s3Event events.S3Event) {
 
 	// Process each record in the S3 event
 	for _, record := range s3Event.Records {
-		s3Entity := record.S3
-		bucketName := s3Entity.Bucket.Name
-		objectKey := s3Entity.Object.Key
-
-		log.Printf("Processing file: %s from bucket: %s", objectKey, bucketName)
-
-		// Load AWS config
-		cfg, err := config.LoadDefaultConfig(ctx)
-		if err != nil {
-			log.Printf("Unable to load SDK config: %v", err)
-			return
+		// Skip the reports written next to previous uploads
+		if storage.IsReportKey(record.S3.Object.Key) {
+			log.Printf("Skipping validation report: %s", record.S3.Object.Key)
+			continue
 		}
-		log.Println("AWS SDK config loaded successfully")
 
-		// Retrieve secrets
-		secretName := os.Getenv("SECRETS_MANAGER_NAME") // Set this in Lambda environment variables
-		secrets, err := getSecrets(ctx, secretName, cfg)
-		if err != nil {
-			log.Printf("Failed to retrieve secrets: %v", err)
-			return
-		}
-		log.Println("Secrets retrieved successfully from AWS Secrets Manager")
-
-		// Access secrets
-		dbUser := secrets["DB_USER"]
-		dbPassword := secrets["DB_PASSWORD"]
-		emailUser := secrets["EMAIL_USER"]
-		emailPassword := secrets["EMAIL_PASSWORD"]
-
-		// Load other environment variables
-		dbName := os.Getenv("DB_NAME")
-		dbHost := os.Getenv("DB_HOST")
-		smtpHost := os.Getenv("SMTP_HOST")
-		smtpPortStr := os.Getenv("SMTP_PORT")
-		fromEmail := emailUser
-
-		// Convert SMTP port from string to int
-		smtpPort, err := strconv.Atoi(smtpPortStr)
-		if err != nil {
-			log.Printf("Invalid SMTP port: %v", err)
+		if err := processRecord(ctx, record); err != nil {
+			log.Printf("Could not process file %s: %v", record.S3.Object.Key, err)
 			continue
 		}
+		log.Printf("Successfully processed file: %s", record.S3.Object.Key)
+	}
+}
+
+// processRecord processes the file of one S3 event record. The database
+// connection it opens is closed before the next record is processed.
+func processRecord(ctx context.Context, record events.S3EventRecord) error {
+	s3Entity := record.S3
+	bucketName := s3Entity.Bucket.Name
+	objectKey := s3Entity.Object.Key
+
+	log.Printf("Processing file: %s from bucket: %s", objectKey, bucketName)
+
+	// Load AWS config
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load SDK config: %w", err)
+	}
+	log.Println("AWS SDK config loaded successfully")
+
+	// Retrieve secrets
+	secretName := os.Getenv("SECRETS_MANAGER_NAME") // Set this in Lambda environment variables
+	secrets, err := getSecrets(ctx, secretName, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve secrets: %w", err)
+	}
+	log.Println("Secrets retrieved successfully from AWS Secrets Manager")
 
-		// Build the DSN (Data Source Name) for MySQL connection
-		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", dbUser, dbPassword, dbHost, dbName)
+	// Access secrets
+	dbUser := secrets["DB_USER"]
+	dbPassword := secrets["DB_PASSWORD"]
+	emailUser := secrets["EMAIL_USER"]
+	emailPassword := secrets["EMAIL_PASSWORD"]
 
-		// Open the database connection
-		db, err := sql.Open("mysql", dsn)
+	// Load other environment variables
+	dbName := os.Getenv("DB_NAME")
+	dbHost := os.Getenv("DB_HOST")
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPortStr := os.Getenv("SMTP_PORT")
+	statementYearStr := os.Getenv("STATEMENT_YEAR")
+	defaultCurrency := os.Getenv("DEFAULT_CURRENCY")
+	validationPolicyStr := os.Getenv("VALIDATION_POLICY")
+	operatorEmail := os.Getenv("OPERATOR_EMAIL")
+	summaryModeStr := os.Getenv("SUMMARY_MODE")
+	templateDir := os.Getenv("TEMPLATE_DIR")
+	statementAttachmentsStr := os.Getenv("STATEMENT_ATTACHMENTS")
+	replyTo := os.Getenv("REPLY_TO")
+	summaryBcc := os.Getenv("SUMMARY_BCC")
+	smtpRateLimitStr := os.Getenv("SMTP_RATE_LIMIT")
+	summaryWorkersStr := os.Getenv("SUMMARY_WORKERS")
+	fromEmail := emailUser
+
+	// Convert SMTP port from string to int
+	smtpPort, err := strconv.Atoi(smtpPortStr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP port: %w", err)
+	}
+
+	// Convert the SMTP rate limit in messages per second (no limit by default)
+	smtpRateLimit := 0.0
+	if smtpRateLimitStr != "" {
+		smtpRateLimit, err = strconv.ParseFloat(smtpRateLimitStr, 64)
 		if err != nil {
-			log.Printf("Could not connect to the database: %v", err)
-			continue
+			return fmt.Errorf("invalid SMTP rate limit: %w", err)
 		}
-		defer db.Close()
+	}
 
-		// Test the database connection
-		if err = db.Ping(); err != nil {
-			log.Printf("Failed to ping database: %v", err)
-			continue
-		}
-		log.Println("Successfully connected to the database")
-
-		// Initialize repositories and services
-		transactionRepo := database.NewMySQLTransactionRepo(db)
-		csvReader := file.NewCSVReader()
-		processTransactions := usecases.NewProcessTransactions(transactionRepo, csvReader)
-		generateSummary := usecases.NewGenerateSummary(transactionRepo)
-		emailService := email.NewGomailService(smtpHost, smtpPort, emailUser, emailPassword, fromEmail)
-		sendSummaryEmail := usecases.NewSendSummaryEmail(generateSummary, emailService)
-
-		// Create AWS S3 client using default credentials
-		s3Client := s3.NewFromConfig(cfg)
-
-		// Read the CSV file from S3
-		log.Printf("Reading CSV file from S3: %s/%s", bucketName, objectKey)
-		csvFileReader, err := readCSVFromS3(ctx, s3Client, bucketName, objectKey)
+	// Convert the number of accounts summarized at the same time (defaults to 4)
+	summaryWorkers := 0
+	if summaryWorkersStr != "" {
+		summaryWorkers, err = strconv.Atoi(summaryWorkersStr)
 		if err != nil {
-			log.Printf("Failed to read CSV from S3: %v", err)
-			continue
+			return fmt.Errorf("invalid summary workers: %w", err)
 		}
-		log.Println("CSV file read successfully from S3")
+	}
 
-		// Execute the ProcessTransactions use case
-		accountToTransactions, err := processTransactions.Execute(csvFileReader)
+	// Convert the statement year for short M/DD dates (defaults to the current year)
+	statementYear := 0
+	if statementYearStr != "" {
+		statementYear, err = strconv.Atoi(statementYearStr)
 		if err != nil {
-			log.Printf("Could not process transactions: %v", err)
-			continue
+			return fmt.Errorf("invalid statement year: %w", err)
 		}
-		log.Println("Transactions processed successfully")
+	}
 
-		// Send summary emails
-		if err := sendSummaryEmail.Execute(accountToTransactions); err != nil {
-			log.Printf("Could not send summary email: %v", err)
-			continue
-		}
-		log.Println("Summary emails sent successfully")
+	// Choose what happens to files with invalid rows (defaults to reject-file)
+	validationPolicy, err := usecases.ParseValidationPolicy(validationPolicyStr)
+	if err != nil {
+		return fmt.Errorf("invalid validation policy: %w", err)
+	}
 
-		log.Printf("Successfully processed file: %s", objectKey)
+	// Choose which transactions the summaries cover (defaults to upload)
+	summaryMode, err := usecases.ParseSummaryMode(summaryModeStr)
+	if err != nil {
+		return fmt.Errorf("invalid summary mode: %w", err)
+	}
+
+	// Load the email templates (TEMPLATE_DIR overrides the embedded defaults)
+	renderer, err := emailtemplate.NewTemplateRenderer(templateDir)
+	if err != nil {
+		return fmt.Errorf("could not load email templates: %w", err)
+	}
+
+	// Choose the statements attached to summary emails (none by default)
+	statements, err := statement.ParseGenerators(statementAttachmentsStr, renderer)
+	if err != nil {
+		return fmt.Errorf("invalid statement attachments: %w", err)
+	}
+
+	// Build the DSN (Data Source Name) for MySQL connection
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", dbUser, dbPassword, dbHost, dbName)
+
+	// Open the database connection
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return fmt.Errorf("could not connect to the database: %w", err)
+	}
+	defer db.Close()
+
+	// Test the database connection
+	if err = db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	log.Println("Successfully connected to the database")
+
+	// Create AWS S3 client using default credentials
+	s3Client := s3.NewFromConfig(cfg)
+
+	// Initialize repositories and services
+	transactionRepo := database.NewMySQLTransactionRepo(db)
+	fileReader := file.NewDefaultReader(statementYear, defaultCurrency)
+	emailService := email.NewGomailService(smtpHost, smtpPort, emailUser, emailPassword, fromEmail)
+	emailService.RateLimit = smtpRateLimit
+	emailService.BeginSession() // One SMTP connection for every email of the file
+	defer emailService.EndSession()
+	processTransactions := usecases.NewProcessTransactions(transactionRepo, fileReader)
+	processTransactions.Policy = validationPolicy
+	processTransactions.ErrorReporters = []interfaces.ErrorReporter{storage.NewS3ErrorReporter(s3Client)}
+	if operatorEmail != "" {
+		processTransactions.ErrorReporters = append(processTransactions.ErrorReporters, email.NewErrorReportSender(emailService, operatorEmail))
+	}
+	generateSummary := usecases.NewGenerateSummary(transactionRepo)
+	generateSummary.Mode = summaryMode
+	sendSummaryEmail := usecases.NewSendSummaryEmail(generateSummary, emailService, renderer)
+	sendSummaryEmail.Statements = statements
+	sendSummaryEmail.ReplyTo = replyTo
+	sendSummaryEmail.Bcc = email.ParseAddresses(summaryBcc)
+	if summaryWorkers > 0 {
+		sendSummaryEmail.Workers = summaryWorkers
 	}
+	dispatchOutbox := usecases.NewDispatchOutbox(transactionRepo, transactionRepo, sendSummaryEmail)
+	ingestUpload := usecases.NewIngestUpload(transactionRepo, processTransactions, dispatchOutbox)
+
+	// Every processing of the object is recorded as an ingestion job
+	job := &entities.IngestionJob{
+		Source: fmt.Sprintf("s3://%s/%s", bucketName, objectKey),
+		Bucket: bucketName,
+		Key:    objectKey,
+		ETag:   s3Entity.Object.ETag,
+	}
+
+	// Stream the file from S3, so large files never sit in memory
+	log.Printf("Reading file from S3: %s/%s", bucketName, objectKey)
+	body, contentType, err := openS3Object(ctx, s3Client, bucketName, objectKey)
+	if err != nil {
+		err = fmt.Errorf("failed to read file from S3: %w", err)
+		ingestUpload.Fail(ctx, job, err)
+		return err
+	}
+	defer body.Close()
+
+	// Store the transactions, then send the summary emails of this upload
+	// until the Lambda deadline cancels ctx. Emails that fail stay in the
+	// outbox and are retried later.
+	// The format is detected from the key extension, content type or content
+	fileInfo := entities.FileInfo{Source: job.Source, ContentType: contentType}
+	report, err := ingestUpload.Execute(ctx, job, fileInfo, body)
+	if report != nil {
+		for _, outcome := range report.Outcomes {
+			if outcome.Status != usecases.SummarySent {
+				log.Printf("Summary for account %s %s: %v", outcome.AccountID, outcome.Status, outcome.Err)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("ingestion job %d: %w", job.ID, err)
+	}
+	log.Printf("%d of %d summary emails sent", report.Count(usecases.SummarySent), len(report.Outcomes))
+
+	return nil
 }
 
 func main() {
 	lambda.Start(handler)
 }
 
-// Helper function to read CSV from S3
-func readCSVFromS3(ctx context.Context, client *s3.Client, bucket, key string) (*csv.Reader, error) {
+// Helper function to open an S3 object for streaming. It returns the body and
+// the content type of the object. The caller must close the body.
+func openS3Object(ctx context.Context, client *s3.Client, bucket, key string) (io.ReadCloser, string, error) {
 	// Get the object from S3
 	output, err := client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %v", err)
-	}
-
-	// Ensure the response body is closed after the reader finishes
-	// Use io.ReadAll to fully read the content into memory first
-	defer output.Body.Close()
-
-	// Copy the response body to a buffer (to avoid closing issues)
-	buffer, err := io.ReadAll(output.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read object body: %v", err)
+		return nil, "", fmt.Errorf("failed to get object: %w", err)
 	}
 
-	// Create a new CSV reader from the buffer
-	csvReader := csv.NewReader(bytes.NewReader(buffer))
-
-	return csvReader, nil
+	return output.Body, aws.ToString(output.ContentType), nil
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Config holds the database and SMTP settings used by the CLI runner.
+// The JSON keys match the environment variables and the Secrets Manager
+// secret used by the Lambda, so the same names work everywhere.
+type Config struct {
+	DBUser               string `json:"DB_USER"`
+	DBPassword           string `json:"DB_PASSWORD"`
+	DBHost               string `json:"DB_HOST"`
+	DBName               string `json:"DB_NAME"`
+	SMTPHost             string `json:"SMTP_HOST"`
+	SMTPPort             string `json:"SMTP_PORT"`
+	EmailUser            string `json:"EMAIL_USER"`
+	EmailPassword        string `json:"EMAIL_PASSWORD"`
+	EmailFrom            string `json:"EMAIL_FROM"`
+	StatementYear        string `json:"STATEMENT_YEAR"`
+	DefaultCurrency      string `json:"DEFAULT_CURRENCY"`
+	ValidationPolicy     string `json:"VALIDATION_POLICY"`
+	OperatorEmail        string `json:"OPERATOR_EMAIL"`
+	SummaryMode          string `json:"SUMMARY_MODE"`
+	TemplateDir          string `json:"TEMPLATE_DIR"`
+	StatementAttachments string `json:"STATEMENT_ATTACHMENTS"`
+	ReplyTo              string `json:"REPLY_TO"`
+	SummaryBcc           string `json:"SUMMARY_BCC"`
+	SMTPRateLimit        string `json:"SMTP_RATE_LIMIT"`
+	SummaryWorkers       string `json:"SUMMARY_WORKERS"`
+}
+
+// configField binds a flag name and environment variable to a Config field.
+type configField struct {
+	flagName string
+	envName  string
+	usage    string
+	target   func(cfg *Config) *string
+}
+
+var configFields = []configField{
+	{"db-user", "DB_USER", "database user", func(c *Config) *string { return &c.DBUser }},
+	{"db-password", "DB_PASSWORD", "database password", func(c *Config) *string { return &c.DBPassword }},
+	{"db-host", "DB_HOST", "database host[:port]", func(c *Config) *string { return &c.DBHost }},
+	{"db-name", "DB_NAME", "database name", func(c *Config) *string { return &c.DBName }},
+	{"smtp-host", "SMTP_HOST", "SMTP server host", func(c *Config) *string { return &c.SMTPHost }},
+	{"smtp-port", "SMTP_PORT", "SMTP server port", func(c *Config) *string { return &c.SMTPPort }},
+	{"email-user", "EMAIL_USER", "SMTP user", func(c *Config) *string { return &c.EmailUser }},
+	{"email-password", "EMAIL_PASSWORD", "SMTP password", func(c *Config) *string { return &c.EmailPassword }},
+	{"email-from", "EMAIL_FROM", "sender address (defaults to the SMTP user)", func(c *Config) *string { return &c.EmailFrom }},
+	{"statement-year", "STATEMENT_YEAR", "year for short M/DD dates (defaults to the current year)", func(c *Config) *string { return &c.StatementYear }},
+	{"default-currency", "DEFAULT_CURRENCY", "currency for rows without one (defaults to USD)", func(c *Config) *string { return &c.DefaultCurrency }},
+	{"validation-policy", "VALIDATION_POLICY", "handling of invalid rows: reject-file, skip-bad-rows or quarantine", func(c *Config) *string { return &c.ValidationPolicy }},
+	{"operator-email", "OPERATOR_EMAIL", "address that receives validation error reports", func(c *Config) *string { return &c.OperatorEmail }},
+	{"summary-mode", "SUMMARY_MODE", "transactions covered by summaries: upload or lifetime", func(c *Config) *string { return &c.SummaryMode }},
+	{"summary-workers", "SUMMARY_WORKERS", "accounts whose summaries are sent at the same time (defaults to 4)", func(c *Config) *string { return &c.SummaryWorkers }},
+	{"template-dir", "TEMPLATE_DIR", "directory with email templates overriding the defaults", func(c *Config) *string { return &c.TemplateDir }},
+	{"statement-attachments", "STATEMENT_ATTACHMENTS", "statements attached to summary emails: csv, pdf or csv,pdf", func(c *Config) *string { return &c.StatementAttachments }},
+	{"reply-to", "REPLY_TO", "Reply-To address of summary emails", func(c *Config) *string { return &c.ReplyTo }},
+	{"smtp-rate-limit", "SMTP_RATE_LIMIT", "maximum emails sent per second (no limit by default)", func(c *Config) *string { return &c.SMTPRateLimit }},
+	{"summary-bcc", "SUMMARY_BCC", "comma-separated addresses that receive a blind copy of every summary", func(c *Config) *string { return &c.SummaryBcc }},
+}
+
+// commandFlags holds the flag set of a command along with the flags shared by
+// every command: the config file path and one flag per Config field.
+type commandFlags struct {
+	*flag.FlagSet
+	configPath *string
+	values     *Config
+}
+
+// newCommandFlags creates the flag set for a command. The usage line is printed
+// before the list of flags.
+func newCommandFlags(name, usage string) *commandFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flags := &commandFlags{
+		FlagSet:    fs,
+		configPath: fs.String("config", "", "path to a JSON config file"),
+		values:     registerConfigFlags(fs),
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return flags
+}
+
+// parseConfig parses the command-line arguments and loads the configuration.
+func (f *commandFlags) parseConfig(args []string) (*Config, error) {
+	f.Parse(args)
+	return loadConfig(f.FlagSet, f.values, *f.configPath)
+}
+
+// registerConfigFlags declares one flag per Config field on the given flag set.
+func registerConfigFlags(fs *flag.FlagSet) *Config {
+	flagValues := &Config{}
+	for _, field := range configFields {
+		fs.StringVar(field.target(flagValues), field.flagName, "", field.usage+" (env "+field.envName+")")
+	}
+	return flagValues
+}
+
+// loadConfig builds the final configuration. Values are resolved in order of
+// precedence: command-line flags, environment variables, then the config file.
+func loadConfig(fs *flag.FlagSet, flagValues *Config, configPath string) (*Config, error) {
+	cfg := &Config{}
+
+	// Load the config file first so everything else can override it
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("could not parse config file: %w", err)
+		}
+	}
+
+	// Override with environment variables
+	for _, field := range configFields {
+		if value, ok := os.LookupEnv(field.envName); ok {
+			*field.target(cfg) = value
+		}
+	}
+
+	// Override with the flags that were explicitly set
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	for _, field := range configFields {
+		if setFlags[field.flagName] {
+			*field.target(cfg) = *field.target(flagValues)
+		}
+	}
+
+	if cfg.EmailFrom == "" {
+		cfg.EmailFrom = cfg.EmailUser
+	}
+
+	return cfg, nil
+}
+
+// DSN builds the Data Source Name for the MySQL connection.
+func (c *Config) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s", c.DBUser, c.DBPassword, c.DBHost, c.DBName)
+}
+
+// Port converts the SMTP port from string to int.
+func (c *Config) Port() (int, error) {
+	port, err := strconv.Atoi(c.SMTPPort)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SMTP port: %w", err)
+	}
+	return port, nil
+}
+
+// Year converts the statement year from string to int. Zero means the current year.
+func (c *Config) Year() (int, error) {
+	if c.StatementYear == "" {
+		return 0, nil
+	}
+	year, err := strconv.Atoi(c.StatementYear)
+	if err != nil {
+		return 0, fmt.Errorf("invalid statement year: %w", err)
+	}
+	return year, nil
+}
+
+// RateLimit converts the SMTP rate limit from string to messages per second.
+// Zero means no limit.
+func (c *Config) RateLimit() (float64, error) {
+	if c.SMTPRateLimit == "" {
+		return 0, nil
+	}
+	rateLimit, err := strconv.ParseFloat(c.SMTPRateLimit, 64)
+	if err != nil || rateLimit < 0 {
+		return 0, fmt.Errorf("invalid SMTP rate limit %q", c.SMTPRateLimit)
+	}
+	return rateLimit, nil
+}
+
+// Workers converts the number of summary workers from string to int. Zero
+// keeps the default.
+func (c *Config) Workers() (int, error) {
+	if c.SummaryWorkers == "" {
+		return 0, nil
+	}
+	workers, err := strconv.Atoi(c.SummaryWorkers)
+	if err != nil || workers < 1 {
+		return 0, fmt.Errorf("invalid summary workers %q", c.SummaryWorkers)
+	}
+	return workers, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"DB_USER": "file-user", "DB_HOST": "file-host", "EMAIL_USER": "file@example.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		configPath string
+		want       Config
+	}{
+		{
+			name:       "config file",
+			configPath: configFile,
+			want:       Config{DBUser: "file-user", DBHost: "file-host", EmailUser: "file@example.com", EmailFrom: "file@example.com"},
+		},
+		{
+			name:       "environment overrides the config file",
+			env:        map[string]string{"DB_USER": "env-user"},
+			configPath: configFile,
+			want:       Config{DBUser: "env-user", DBHost: "file-host", EmailUser: "file@example.com", EmailFrom: "file@example.com"},
+		},
+		{
+			name:       "flags override the environment",
+			args:       []string{"-db-user", "flag-user", "-email-from", "from@example.com"},
+			env:        map[string]string{"DB_USER": "env-user"},
+			configPath: configFile,
+			want:       Config{DBUser: "flag-user", DBHost: "file-host", EmailUser: "file@example.com", EmailFrom: "from@example.com"},
+		},
+		{
+			name: "flags alone",
+			args: []string{"-smtp-host", "smtp.example.com", "-smtp-port", "587"},
+			want: Config{SMTPHost: "smtp.example.com", SMTPPort: "587"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear the variables the test does not set
+			for _, field := range configFields {
+				t.Setenv(field.envName, "")
+				os.Unsetenv(field.envName)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			fs := flag.NewFlagSet("tsummary", flag.ContinueOnError)
+			flagValues := registerConfigFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := loadConfig(fs, flagValues, tt.configPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cfg != tt.want {
+				t.Errorf("loadConfig() = %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(invalid, []byte("DB_USER=user"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		configPath string
+	}{
+		{name: "missing file", configPath: filepath.Join(t.TempDir(), "missing.json")},
+		{name: "invalid JSON", configPath: invalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("tsummary", flag.ContinueOnError)
+			if _, err := loadConfig(fs, registerConfigFlags(fs), tt.configPath); err == nil {
+				t.Error("loadConfig() returned no error")
+			}
+		})
+	}
+}
+
+func TestConfigPort(t *testing.T) {
+	tests := []struct {
+		port    string
+		want    int
+		wantErr bool
+	}{
+		{port: "587", want: 587},
+		{port: "", wantErr: true},
+		{port: "smtp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := (&Config{SMTPPort: tt.port}).Port()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Port() for %q = %d, %v, want %d, error %v", tt.port, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"transactions-summary/internal/infrastructure/database"
+	"transactions-summary/internal/usecases"
+)
+
+// dispatchCommand delivers the summary emails of the outbox that are due,
+// including earlier failures whose retry delay has passed.
+func dispatchCommand(args []string) error {
+	flags := newCommandFlags("dispatch", "tsummary dispatch [flags]")
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	dispatchOutbox, closeAll, err := openDispatcher(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return dispatch(ctx, dispatchOutbox, "")
+}
+
+// resendCommand puts the summary emails of an account, an upload, or both back
+// in the outbox and delivers them.
+func resendCommand(args []string) error {
+	flags := newCommandFlags("resend", "tsummary resend [flags] -account ID | -upload ID")
+	account := flags.String("account", "", "resend the summaries of this account")
+	upload := flags.String("upload", "", "resend the summaries of this upload, e.g. job:42 as listed by status")
+	queueOnly := flags.Bool("queue-only", false, "requeue the summaries without sending them now")
+
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	dispatchOutbox, closeAll, err := openDispatcher(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeAll()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	count, err := usecases.NewResendSummaries(dispatchOutbox.OutboxRepo).Execute(ctx, *account, *upload)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d summaries requeued\n", count)
+	if *queueOnly || count == 0 {
+		return nil
+	}
+	return dispatch(ctx, dispatchOutbox, *upload)
+}
+
+// openDispatcher connects to the database and the SMTP server and builds the
+// DispatchOutbox use case. The returned function closes both connections.
+func openDispatcher(cfg *Config) (*usecases.DispatchOutbox, func(), error) {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	gomailService, err := openEmailService(cfg)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	closeAll := func() {
+		gomailService.EndSession()
+		db.Close()
+	}
+
+	repo := database.NewMySQLTransactionRepo(db)
+	sendSummaryEmail, err := newSendSummaryEmail(cfg, repo, gomailService)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	return usecases.NewDispatchOutbox(repo, repo, sendSummaryEmail), closeAll, nil
+}
@@ -0,0 +1,74 @@
+// Command tsummary runs the transactions summary pipeline against a local
+// file or stdin, without Lambda, S3 or Secrets Manager.
+//
+// Usage:
+//
+//	tsummary [process] [flags] [file]
+//	tsummary reconcile [flags]
+//	tsummary dispatch [flags]
+//	tsummary resend [flags] -account ID | -upload ID
+//	tsummary status [flags] KEY
+//	tsummary serve [flags]
+//
+// The process command (the default) reads the transactions from the given
+// file, or from stdin when no file (or "-") is given, and sends the summary
+// emails. The reconcile command recomputes every account balance from the
+// stored transactions and reports any drift. The dispatch command retries the
+// summary emails still waiting in the outbox, and resend sends them again. The
+// status command prints how an upload was processed, and serve answers the
+// same question over HTTP.
+//
+// Database and SMTP settings come from flags, environment variables or a JSON
+// config file.
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// commands maps each subcommand name to its implementation.
+var commands = map[string]func(args []string) error{
+	"process":   processCommand,
+	"reconcile": reconcileCommand,
+	"dispatch":  dispatchCommand,
+	"resend":    resendCommand,
+	"status":    statusCommand,
+	"serve":     serveCommand,
+}
+
+func main() {
+	// Default to the process command so "tsummary file.csv" keeps working
+	name, args := "process", os.Args[1:]
+	if len(args) > 0 {
+		if _, exists := commands[args[0]]; exists {
+			name, args = args[0], args[1:]
+		}
+	}
+
+	if err := commands[name](args); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// openDatabase opens and pings the MySQL database described by cfg.
+func openDatabase(cfg *Config) (*sql.DB, error) {
+	// Open the database connection
+	db, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the database: %w", err)
+	}
+
+	// Test the database connection
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	log.Println("Successfully connected to the database")
+
+	return db, nil
+}
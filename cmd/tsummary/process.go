@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/database"
+	"transactions-summary/internal/infrastructure/email"
+	"transactions-summary/internal/infrastructure/file"
+	"transactions-summary/internal/infrastructure/memory"
+	"transactions-summary/internal/interfaces"
+	"transactions-summary/internal/usecases"
+)
+
+// processCommand stores the transactions of a file and emails the summaries.
+// With -dry-run it uses an in-memory repository seeded from the -accounts
+// fixture and prints the emails instead of sending them.
+func processCommand(args []string) error {
+	flags := newCommandFlags("process", "tsummary [process] [flags] [file]\n\nReads the file from stdin when no file or \"-\" is given.")
+	dryRun := flags.Bool("dry-run", false, "use an in-memory repository and print emails instead of sending them")
+	accountsPath := flags.String("accounts", "", "JSON or YAML accounts fixture to seed the in-memory repository (with -dry-run)")
+	source := flags.String("source", "", "name used to derive transaction IDs (defaults to the file path, or \"stdin\")")
+	reportDir := flags.String("report-dir", "", "directory for the error and quarantine reports of stdin input (defaults to the system temp directory)")
+	format := flags.String("format", "", "format of the file: csv, ofx, jsonl or parquet (detected from the extension or content by default)")
+
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	var transactionRepo summaryStore
+	var emailService interfaces.EmailSender
+
+	if *dryRun {
+		memoryRepo := memory.NewInMemoryTransactionRepo()
+		if *accountsPath != "" {
+			if err := memoryRepo.LoadAccountsFixture(*accountsPath); err != nil {
+				return fmt.Errorf("could not seed accounts: %w", err)
+			}
+		}
+		log.Println("Dry run: using in-memory repository, emails will be printed")
+		transactionRepo = memoryRepo
+		emailService = email.NewLogEmailSender(os.Stdout)
+	} else {
+		db, err := openDatabase(cfg)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		gomailService, err := openEmailService(cfg)
+		if err != nil {
+			return err
+		}
+		defer gomailService.EndSession()
+		transactionRepo = database.NewMySQLTransactionRepo(db)
+		emailService = gomailService
+	}
+
+	// Stop handing out summaries on Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return run(ctx, cfg, transactionRepo, emailService, flags.Arg(0), *source, *format, *reportDir)
+}
+
+// run wires the use cases, processes a single input file and delivers the
+// summary emails it enqueued.
+func run(ctx context.Context, cfg *Config, transactionRepo summaryStore, emailService interfaces.EmailSender, path, source, format, reportDir string) error {
+	statementYear, err := cfg.Year()
+	if err != nil {
+		return err
+	}
+	validationPolicy, err := usecases.ParseValidationPolicy(cfg.ValidationPolicy)
+	if err != nil {
+		return err
+	}
+	sendSummaryEmail, err := newSendSummaryEmail(cfg, transactionRepo, emailService)
+	if err != nil {
+		return err
+	}
+
+	input, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	// Re-processing the same source yields the same transaction IDs
+	if source == "" {
+		source = sourceName(path)
+	}
+
+	// Initialize use cases
+	fileReader := file.NewDefaultReader(statementYear, cfg.DefaultCurrency)
+	processTransactions := usecases.NewProcessTransactions(transactionRepo, fileReader)
+	processTransactions.Policy = validationPolicy
+	// Reports of a local file go next to it, those of stdin to the report directory
+	if reportDir == "" {
+		reportDir = os.TempDir()
+	}
+	processTransactions.ErrorReporters = []interfaces.ErrorReporter{file.NewLocalErrorReporter(reportDir)}
+	if cfg.OperatorEmail != "" {
+		processTransactions.ErrorReporters = append(processTransactions.ErrorReporters, email.NewErrorReportSender(emailService, cfg.OperatorEmail))
+	}
+	dispatchOutbox := usecases.NewDispatchOutbox(transactionRepo, transactionRepo, sendSummaryEmail)
+	ingestUpload := usecases.NewIngestUpload(transactionRepo, processTransactions, dispatchOutbox)
+
+	// Store the transactions and deliver the summary emails they enqueued
+	job := &entities.IngestionJob{Key: inputKey(path)}
+	fileInfo := entities.FileInfo{Source: source, Format: format}
+	report, err := ingestUpload.Execute(ctx, job, fileInfo, input)
+	log.Printf("Ingestion job %d for %s: %s", job.ID, job.Key, job.Status)
+	return checkSummaryReport(report, err)
+}
+
+// inputKey returns the key that identifies the input at path in the
+// ingestion jobs: its absolute path, or "stdin".
+func inputKey(path string) string {
+	if path == "" || path == "-" {
+		return "stdin"
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// sourceName returns the default source name for the input at path.
+func sourceName(path string) string {
+	if key := inputKey(path); filepath.IsAbs(key) {
+		return "file://" + key
+	}
+	return inputKey(path)
+}
+
+// openInput opens the file at path, or stdin when path is empty or "-".
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		log.Println("Reading from stdin")
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	log.Printf("Reading file: %s", path)
+	return f, nil
+}
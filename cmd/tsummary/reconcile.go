@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"transactions-summary/internal/infrastructure/database"
+	"transactions-summary/internal/usecases"
+)
+
+// reconcileCommand recomputes every account balance from the transactions
+// table and reports the accounts whose stored balances drifted.
+func reconcileCommand(args []string) error {
+	flags := newCommandFlags("reconcile", "tsummary reconcile [flags]")
+	fix := flags.Bool("fix", false, "overwrite drifted balances with the recomputed ones")
+
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	reconcileBalances := usecases.NewReconcileBalances(database.NewMySQLTransactionRepo(db))
+	drifts, err := reconcileBalances.Execute(ctx, *fix)
+	if err != nil {
+		return fmt.Errorf("could not reconcile balances: %w", err)
+	}
+
+	if len(drifts) == 0 {
+		log.Println("All account balances match their transactions")
+		return nil
+	}
+
+	fmt.Printf("%-12s %15s %15s %15s %15s\n", "ACCOUNT", "STORED DEBIT", "STORED CREDIT", "ACTUAL DEBIT", "ACTUAL CREDIT")
+	for _, drift := range drifts {
+		fmt.Printf("%-12s %15s %15s %15s %15s\n", drift.AccountID, drift.StoredDebit, drift.StoredCredit, drift.ComputedDebit, drift.ComputedCredit)
+	}
+
+	if *fix {
+		log.Printf("Fixed %d drifted accounts", len(drifts))
+		return nil
+	}
+	return fmt.Errorf("%d accounts drifted, run with -fix to correct them", len(drifts))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/database"
+	"transactions-summary/internal/usecases"
+)
+
+// statusCommand prints the ingestion jobs and summary emails of an upload.
+func statusCommand(args []string) error {
+	flags := newCommandFlags("status", "tsummary status [flags] KEY\n\nKEY is the S3 object key, the source (e.g. s3://bucket/key) or the absolute path of a local file.")
+	asJSON := flags.Bool("json", false, "print the status as JSON")
+
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("an upload key is required")
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repo := database.NewMySQLTransactionRepo(db)
+	statuses, err := usecases.NewGetUploadStatus(repo, repo).Execute(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+	for _, status := range statuses {
+		printUploadStatus(status)
+	}
+	return nil
+}
+
+// printUploadStatus prints the jobs of an upload, newest first, followed by
+// its summary emails.
+func printUploadStatus(status usecases.UploadStatus) {
+	fmt.Printf("%s\n\n", status.Source)
+	fmt.Printf("%-6s %-10s %-20s %9s %6s %9s %8s %8s  %s\n", "JOB", "STATUS", "STARTED", "DURATION", "READ", "DUPLICATE", "INSERTED", "REJECTED", "ERROR")
+	for _, job := range status.Jobs {
+		duration := "-"
+		if !job.FinishedAt.IsZero() {
+			duration = job.FinishedAt.Sub(job.StartedAt).Round(time.Second).String()
+		}
+		fmt.Printf("%-6d %-10s %-20s %9s %6d %9d %8d %8d  %s\n", job.ID, job.Status, job.StartedAt.Local().Format(time.DateTime),
+			duration, job.RowsRead, job.RowsDuplicate, job.RowsInserted, job.RowsRejected, job.Error)
+	}
+
+	if len(status.Emails) > 0 {
+		fmt.Printf("\n%-10s %-12s %-8s %8s  %s\n", "UPLOAD", "ACCOUNT", "EMAIL", "ATTEMPTS", "ERROR")
+		for _, entry := range status.Emails {
+			fmt.Printf("%-10s %-12s %-8s %8d  %s\n", entry.UploadID, entry.AccountID, entry.Status, entry.Attempts, entry.LastError)
+		}
+	}
+	fmt.Println()
+}
+
+// serveCommand serves the status of uploads over HTTP:
+//
+//	GET /uploads/status?key=KEY
+//
+// answers with the same JSON as "tsummary status -json KEY".
+func serveCommand(args []string) error {
+	flags := newCommandFlags("serve", "tsummary serve [flags]")
+	addr := flags.String("addr", ":8080", "address to listen on")
+
+	cfg, err := flags.parseConfig(args)
+	if err != nil {
+		return fmt.Errorf("could not load configuration: %w", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	repo := database.NewMySQLTransactionRepo(db)
+	getUploadStatus := usecases.NewGetUploadStatus(repo, repo)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /uploads/status", func(w http.ResponseWriter, r *http.Request) {
+		serveUploadStatus(w, r, getUploadStatus)
+	})
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	// Stop accepting requests on Ctrl+C and let the running ones finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving upload status on %s", *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not serve upload status: %w", err)
+	}
+	return nil
+}
+
+// serveUploadStatus writes the status of the upload named by the key query
+// parameter as JSON.
+func serveUploadStatus(w http.ResponseWriter, r *http.Request, getUploadStatus *usecases.GetUploadStatus) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "the key query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := getUploadStatus.Execute(r.Context(), key)
+	switch {
+	case errors.Is(err, entities.ErrUploadNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Could not look up upload status: %v", err)
+		http.Error(w, "could not look up the upload", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		log.Printf("Could not write upload status: %v", err)
+	}
+}
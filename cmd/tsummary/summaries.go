@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"transactions-summary/internal/infrastructure/email"
+	"transactions-summary/internal/infrastructure/emailtemplate"
+	"transactions-summary/internal/infrastructure/statement"
+	"transactions-summary/internal/interfaces"
+	"transactions-summary/internal/usecases"
+)
+
+// summaryStore is a repository that stores the transactions, the outbox of
+// summary emails and the ingestion jobs, as the MySQL and in-memory
+// repositories do.
+type summaryStore interface {
+	interfaces.TransactionRepository
+	interfaces.OutboxRepository
+	interfaces.IngestionJobRepository
+}
+
+// openEmailService creates the SMTP sender and starts a session, so every
+// email of the run shares one connection. Callers must end the session.
+func openEmailService(cfg *Config) (*email.GomailService, error) {
+	smtpPort, err := cfg.Port()
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := cfg.RateLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	gomailService := email.NewGomailService(cfg.SMTPHost, smtpPort, cfg.EmailUser, cfg.EmailPassword, cfg.EmailFrom)
+	gomailService.RateLimit = rateLimit
+	gomailService.BeginSession()
+	return gomailService, nil
+}
+
+// newSendSummaryEmail builds the SendSummaryEmail use case from the summary,
+// template and attachment settings.
+func newSendSummaryEmail(cfg *Config, transactionRepo interfaces.TransactionRepository, emailService interfaces.EmailSender) (*usecases.SendSummaryEmail, error) {
+	summaryMode, err := usecases.ParseSummaryMode(cfg.SummaryMode)
+	if err != nil {
+		return nil, err
+	}
+	summaryWorkers, err := cfg.Workers()
+	if err != nil {
+		return nil, err
+	}
+	renderer, err := emailtemplate.NewTemplateRenderer(cfg.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not load email templates: %w", err)
+	}
+	statements, err := statement.ParseGenerators(cfg.StatementAttachments, renderer)
+	if err != nil {
+		return nil, err
+	}
+
+	generateSummary := usecases.NewGenerateSummary(transactionRepo)
+	generateSummary.Mode = summaryMode
+	sendSummaryEmail := usecases.NewSendSummaryEmail(generateSummary, emailService, renderer)
+	sendSummaryEmail.Statements = statements
+	sendSummaryEmail.ReplyTo = cfg.ReplyTo
+	sendSummaryEmail.Bcc = email.ParseAddresses(cfg.SummaryBcc)
+	if summaryWorkers > 0 {
+		sendSummaryEmail.Workers = summaryWorkers
+	}
+	return sendSummaryEmail, nil
+}
+
+// dispatch delivers the due summary emails of the outbox, only those of
+// uploadID unless it is empty, and prints the ones that did not succeed.
+// Failed emails stay in the outbox for the next dispatch.
+func dispatch(ctx context.Context, dispatchOutbox *usecases.DispatchOutbox, uploadID string) error {
+	report, err := dispatchOutbox.ExecuteUpload(ctx, uploadID)
+	if err != nil {
+		err = fmt.Errorf("could not send summary emails: %w", err)
+	}
+	return checkSummaryReport(report, err)
+}
+
+// checkSummaryReport prints the summary emails that did not succeed and
+// returns err, or an error when any of them failed.
+func checkSummaryReport(report *usecases.SummaryReport, err error) error {
+	if report != nil {
+		printSummaryReport(report)
+	}
+	if err != nil {
+		return err
+	}
+	if failed := report.Count(usecases.SummaryFailed); failed > 0 {
+		return fmt.Errorf("%d of %d summary emails failed and will be retried", failed, len(report.Outcomes))
+	}
+	log.Printf("%d summary emails sent", report.Count(usecases.SummarySent))
+	return nil
+}
+
+// printSummaryReport prints the outcome of every account that is not a plain success.
+func printSummaryReport(report *usecases.SummaryReport) {
+	problems := len(report.Outcomes) - report.Count(usecases.SummarySent)
+	if problems == 0 {
+		return
+	}
+	fmt.Printf("%-12s %-24s %s\n", "ACCOUNT", "STATUS", "ERROR")
+	for _, outcome := range report.Outcomes {
+		if outcome.Status != usecases.SummarySent {
+			fmt.Printf("%-12s %-24s %v\n", outcome.AccountID, outcome.Status, outcome.Err)
+		}
+	}
+}
@@ -1,8 +1,29 @@
 package entities
 
 type Account struct {
-	ID            string  `json:"id"`
-	DebitBalance  float64 `json:"debit_balance"`
-	CreditBalance float64 `json:"credit_balance"`
-	Email         string  `json:"email"`
+	ID            string `json:"id"`
+	DebitBalance  Money  `json:"debit_balance"`  // Sum of debit amounts (zero or negative)
+	CreditBalance Money  `json:"credit_balance"` // Sum of credit amounts
+	Email         string `json:"email"`
+	Currency      string `json:"currency"` // Base currency of the balances, e.g. "USD"
+	Locale        string `json:"locale"`   // Language and region of the emails, e.g. "es-MX"
+}
+
+// ApplyTransaction adds a transaction amount to the matching balance. Balances
+// are kept in the account currency, so transactions in any other currency are
+// left out; the return value reports whether the balances changed.
+func (a *Account) ApplyTransaction(transaction Transaction) bool {
+	if transaction.Amount.Currency != a.Currency {
+		return false
+	}
+
+	switch transaction.Type {
+	case "debit":
+		a.DebitBalance = a.DebitBalance.Add(transaction.Amount)
+	case "credit":
+		a.CreditBalance = a.CreditBalance.Add(transaction.Amount)
+	default:
+		return false
+	}
+	return true
 }
@@ -0,0 +1,21 @@
+package entities
+
+// EmailMessage is an email ready to be sent. TextBody is the plain-text
+// alternative of HTMLBody; either may be empty.
+type EmailMessage struct {
+	To          string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	HTMLBody    string
+	TextBody    string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Filename    string // E.g., "statement.pdf"
+	ContentType string // E.g., "application/pdf"
+	Data        []byte
+}
@@ -0,0 +1,10 @@
+package entities
+
+import "errors"
+
+// ErrAccountNotFound is returned by repositories when an account ID is unknown.
+// Check for it with errors.Is, since repositories add the ID to the message.
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrUploadNotFound is returned when no ingestion job matches an upload.
+var ErrUploadNotFound = errors.New("upload not found")
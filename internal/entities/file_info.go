@@ -0,0 +1,9 @@
+package entities
+
+// FileInfo describes an uploaded file to the file readers.
+type FileInfo struct {
+	Source      string // Names the file, e.g. "s3://bucket/key.csv", and derives transaction IDs
+	ContentType string // MIME type reported by the storage, empty when unknown
+	Format      string // Declared format, e.g. "csv"; empty to detect it
+	UploadID    string // Tags the stored transactions and summary emails, e.g. "job:42"; empty means Source
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+)
+
+// JobStatus is the state of an ingestion job.
+type JobStatus string
+
+const (
+	// JobProcessing jobs are reading and storing their upload.
+	JobProcessing JobStatus = "processing"
+	// JobProcessed jobs stored their transactions. Their summary emails are
+	// tracked in the outbox.
+	JobProcessed JobStatus = "processed"
+	// JobFailed jobs stored nothing, e.g. because the file was rejected.
+	JobFailed JobStatus = "failed"
+)
+
+// IngestionJob records one processing of an uploaded file: where it came
+// from, what happened to its rows and how long it took.
+type IngestionJob struct {
+	ID            int64     `json:"id"`
+	Source        string    `json:"source"` // Derives the IDs of its transactions
+	Bucket        string    `json:"bucket,omitempty"`
+	Key           string    `json:"key"`
+	ETag          string    `json:"etag,omitempty"`
+	Status        JobStatus `json:"status"`
+	RowsRead      int       `json:"rows_read"`      // Data rows in the file, valid or not
+	RowsDuplicate int       `json:"rows_duplicate"` // Valid rows already stored or repeated in the file
+	RowsInserted  int       `json:"rows_inserted"`
+	RowsRejected  int       `json:"rows_rejected"` // Invalid rows
+	StartedAt     time.Time `json:"started_at"`
+	ProcessedAt   time.Time `json:"processed_at"` // Zero until the transactions are stored
+	FinishedAt    time.Time `json:"finished_at"`  // Zero while the job runs
+	Error         string    `json:"error,omitempty"`
+}
+
+// UploadID returns the upload ID of the transactions and summary emails the
+// job stored, e.g. "job:42". Every job has its own, so uploading the same key
+// again never mixes the rows of both uploads.
+func (j IngestionJob) UploadID() string {
+	return "job:" + strconv.FormatInt(j.ID, 10)
+}
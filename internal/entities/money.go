@@ -0,0 +1,190 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when an amount does not state its currency.
+const DefaultCurrency = "USD"
+
+// minorUnitsPerUnit is the number of minor units (cents) in one unit of currency.
+// All supported currencies use two decimal places.
+const minorUnitsPerUnit = 100
+
+// maxAmountDigits bounds parsed amounts so they fit in DECIMAL(15,2) and never overflow int64.
+const maxAmountDigits = 15
+
+// Money is an exact amount of money stored as an integer number of minor units
+// (e.g. cents) plus an ISO 4217 currency code. It never uses floating point.
+type Money struct {
+	Amount   int64  // Minor units, e.g. 6050 for 60.50
+	Currency string // E.g., "USD"
+}
+
+// NewMoney creates a Money value from minor units.
+func NewMoney(minorUnits int64, currency string) Money {
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "+60.5", "-10.30" or "10".
+// At most two decimal places are accepted; the value is never rounded.
+func ParseMoney(value string, currency string) (Money, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if hasPoint && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(fraction) > 2 {
+		return Money{}, fmt.Errorf("invalid amount %q: more than 2 decimal places", value)
+	}
+	if len(whole)+len(fraction) > maxAmountDigits {
+		return Money{}, fmt.Errorf("invalid amount %q: too many digits", value)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	// Pad the fraction to two digits, e.g. "60.5" -> 60 and "50"
+	digits := whole + fraction + strings.Repeat("0", 2-len(fraction))
+	minorUnits, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	if negative {
+		minorUnits = -minorUnits
+	}
+
+	return Money{Amount: minorUnits, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns m + other. A zero Money without currency takes the other currency.
+// Adding amounts in different currencies is a programming error and panics.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.sameCurrency(other)}
+}
+
+// Sub returns m - other, with the same currency rules as Add.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.sameCurrency(other)}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Div divides m by n and rounds half away from zero to the nearest minor unit.
+// It is used for averages; dividing by zero returns zero.
+func (m Money) Div(n int) Money {
+	if n == 0 {
+		return Money{Currency: m.Currency}
+	}
+	quotient := m.Amount / int64(n)
+	remainder := m.Amount % int64(n)
+	if remainder*2 >= int64(n) {
+		quotient++
+	} else if remainder*2 <= -int64(n) {
+		quotient--
+	}
+	return Money{Amount: quotient, Currency: m.Currency}
+}
+
+// IsNegative reports whether m is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// IsZero reports whether m is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) sameCurrency(other Money) string {
+	switch {
+	case m.Currency == other.Currency || other.Currency == "":
+		return m.Currency
+	case m.Currency == "":
+		return other.Currency
+	default:
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency, other.Currency))
+	}
+}
+
+// String formats the amount with two decimals, e.g. "60.50" or "-10.30".
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/minorUnitsPerUnit, amount%minorUnitsPerUnit)
+}
+
+// moneyJSON is the JSON representation of Money. The amount is a decimal
+// string so it never goes through float64.
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount": "60.50", "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes the object form written by MarshalJSON, or a plain
+// number or decimal string in DefaultCurrency, such as 60.5 or "60.50".
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '{' {
+		var value moneyJSON
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		currency := value.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		parsed, err := ParseMoney(value.Amount, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("invalid money value %s: %w", data, err)
+	}
+	parsed, err := ParseMoney(number.String(), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
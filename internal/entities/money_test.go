@@ -0,0 +1,90 @@
+package entities
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "whole", value: "10", want: 1000},
+		{name: "one decimal", value: "+60.5", want: 6050},
+		{name: "two decimals", value: "-10.30", want: -1030},
+		{name: "spaces", value: " 7.25 ", want: 725},
+		{name: "fraction only", value: ".5", want: 50},
+		{name: "zero", value: "0", want: 0},
+		{name: "three decimals", value: "1.234", wantErr: true},
+		{name: "trailing point", value: "1.", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+		{name: "sign only", value: "-", wantErr: true},
+		{name: "letters", value: "12a", wantErr: true},
+		{name: "thousands separator", value: "1,000", wantErr: true},
+		{name: "too many digits", value: "12345678901234567890", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, "USD")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) returned error: %v", tt.value, err)
+			}
+			if got.Amount != tt.want || got.Currency != "USD" {
+				t.Errorf("ParseMoney(%q) = %d %s, want %d USD", tt.value, got.Amount, got.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyDiv(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		n      int
+		want   int64
+	}{
+		{name: "exact", amount: 1000, n: 4, want: 250},
+		{name: "rounds down", amount: 1000, n: 3, want: 333},
+		{name: "rounds half up", amount: 5, n: 2, want: 3},
+		{name: "rounds up", amount: 2000, n: 3, want: 667},
+		{name: "negative rounds half away from zero", amount: -5, n: 2, want: -3},
+		{name: "negative rounds toward zero", amount: -1000, n: 3, want: -333},
+		{name: "negative rounds away from zero", amount: -2000, n: 3, want: -667},
+		{name: "by zero", amount: 1000, n: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMoney(tt.amount, "USD").Div(tt.n)
+			if got.Amount != tt.want || got.Currency != "USD" {
+				t.Errorf("NewMoney(%d).Div(%d) = %d %s, want %d USD", tt.amount, tt.n, got.Amount, got.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   string
+	}{
+		{amount: 6050, want: "60.50"},
+		{amount: -1030, want: "-10.30"},
+		{amount: 5, want: "0.05"},
+		{amount: -5, want: "-0.05"},
+		{amount: 0, want: "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := NewMoney(tt.amount, "USD").String(); got != tt.want {
+			t.Errorf("NewMoney(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
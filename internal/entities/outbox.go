@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// OutboxStatus is the delivery state of a summary email in the outbox.
+type OutboxStatus string
+
+const (
+	// OutboxPending entries are waiting for their first or next attempt.
+	OutboxPending OutboxStatus = "pending"
+	// OutboxSent entries were delivered.
+	OutboxSent OutboxStatus = "sent"
+	// OutboxFailed entries ran out of attempts or cannot be delivered at all.
+	OutboxFailed OutboxStatus = "failed"
+)
+
+// OutboxEntry is a summary email owed to an account for the transactions it
+// received in one upload. Entries are stored together with the transactions,
+// so a summary is never lost when sending fails after the rows are committed.
+type OutboxEntry struct {
+	ID            int64        `json:"id"`
+	UploadID      string       `json:"upload_id"` // Ingestion job of the upload, e.g. "job:42"
+	AccountID     string       `json:"account_id"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"` // When the entry is due for delivery
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	SentAt        time.Time    `json:"sent_at"` // Zero until sent
+}
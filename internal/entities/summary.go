@@ -2,18 +2,32 @@ package entities
 
 // MonthlySummary holds the summary information for a single month.
 type MonthlySummary struct {
-	Month           string  // E.g., "July"
-	NumTransactions int     // Number of transactions in the month
-	AverageCredit   float64 // Average credit amount
-	AverageDebit    float64 // Average debit amount
-	TotalCredits    float64 // Total of all credit transactions
-	TotalDebits     float64 // Total of all debit transactions
+	Month           string // E.g., "July"
+	MonthNumber     int    // 1 (January) to 12 (December)
+	Year            int    // E.g., 2024
+	NumTransactions int    // Number of transactions in the month
+	AverageCredit   Money  // Average credit amount
+	AverageDebit    Money  // Average debit amount
+	TotalCredits    Money  // Total of all credit transactions
+	TotalDebits     Money  // Total of all debit transactions
+	NetAmount       Money  // Credits plus debits of the month
+	ClosingBalance  Money  // Running balance at the end of the month
+}
+
+// CurrencySummary holds the totals and monthly breakdown for one currency.
+type CurrencySummary struct {
+	Currency         string // E.g., "MXN"
+	TotalCredit      Money
+	TotalDebit       Money
+	MonthlySummaries []MonthlySummary // Summary grouped by month, in chronological order
 }
 
 // SummaryResult holds the overall summary data.
 type SummaryResult struct {
-	TotalCredit      float64
-	TotalDebit       float64
-	MonthlySummaries []MonthlySummary // Summary grouped by month
-
+	AccountID  string
+	Locale     string            // Locale of the account, e.g. "es-MX"
+	Currencies []CurrencySummary // One summary per currency, the account currency first
+	// Transactions holds every transaction the summary covers: the upload, plus
+	// the stored history in lifetime mode.
+	Transactions []Transaction
 }
@@ -6,7 +6,13 @@ import "time"
 type Transaction struct {
 	ID              string    `json:"id"`
 	AccountID       string    `json:"account_id"`
-	Amount          float64   `json:"amount"`
+	Amount          Money     `json:"amount"`
 	TransactionDate time.Time `json:"transaction_date"`
 	Type            string    `json:"type"` // "debit" or "credit"
+	Description     string    `json:"description,omitempty"`
+	Merchant        string    `json:"merchant,omitempty"`
+	File            string    `json:"-"`                   // File inside an archive the row was read from, empty otherwise
+	Line            int       `json:"-"`                   // Line of the row in the uploaded file, used to report it
+	Record          []string  `json:"-"`                   // Raw row, kept so it can be quarantined
+	UploadID        string    `json:"upload_id,omitempty"` // Ingestion job of the upload that stored it, e.g. "job:42"
 }
@@ -0,0 +1,83 @@
+package entities
+
+import "fmt"
+
+// RowError describes a row of an uploaded file that failed validation.
+type RowError struct {
+	File   string   // File inside an archive the row was read from, empty otherwise
+	Line   int      // Line number in the file (the header is line 1)
+	Column string   // Column that failed, empty when the whole row is invalid
+	Value  string   // Offending value
+	Reason string   // Why the value was rejected
+	Record []string // Raw row, kept so it can be quarantined
+}
+
+func (e RowError) Error() string {
+	location := fmt.Sprintf("line %d", e.Line)
+	if e.File != "" {
+		location = fmt.Sprintf("%s, line %d", e.File, e.Line)
+	}
+	if e.Column == "" {
+		return fmt.Sprintf("%s: %s", location, e.Reason)
+	}
+	return fmt.Sprintf("%s, column %s: %s: %q", location, e.Column, e.Reason, e.Value)
+}
+
+// DefaultMaxRowErrors is the number of row errors a ValidationReport keeps
+// when MaxRowErrors is not set.
+const DefaultMaxRowErrors = 1000
+
+// ValidationReport collects the rows of a file that failed validation.
+type ValidationReport struct {
+	Source       string     // File the rows were read from
+	Header       []string   // Header row, used to write quarantined rows
+	RowErrors    []RowError // One entry per invalid value, up to MaxRowErrors
+	MaxRowErrors int        // Row errors kept; zero keeps DefaultMaxRowErrors
+	OmittedRows  int        // Invalid rows counted but not kept in RowErrors
+}
+
+// Add records the errors of one invalid row. Once the report holds
+// MaxRowErrors entries further rows are only counted, so a file full of bad
+// rows is not kept in memory.
+func (r *ValidationReport) Add(rowErrors ...RowError) {
+	if len(rowErrors) == 0 {
+		return
+	}
+	limit := r.MaxRowErrors
+	if limit <= 0 {
+		limit = DefaultMaxRowErrors
+	}
+	if len(r.RowErrors) >= limit {
+		r.OmittedRows++
+		return
+	}
+	r.RowErrors = append(r.RowErrors, rowErrors...)
+}
+
+// HasErrors reports whether any row failed validation.
+func (r *ValidationReport) HasErrors() bool {
+	return r != nil && (len(r.RowErrors) > 0 || r.OmittedRows > 0)
+}
+
+// InvalidRows returns the number of distinct rows that failed validation.
+func (r *ValidationReport) InvalidRows() int {
+	if r == nil {
+		return 0
+	}
+	rows := make(map[RowKey]bool)
+	for _, rowError := range r.RowErrors {
+		rows[rowError.Key()] = true
+	}
+	return len(rows) + r.OmittedRows
+}
+
+// RowKey identifies a row across the files of an upload.
+type RowKey struct {
+	File string
+	Line int
+}
+
+// Key returns the row the error belongs to.
+func (e RowError) Key() RowKey {
+	return RowKey{File: e.File, Line: e.Line}
+}
@@ -0,0 +1,32 @@
+package entities
+
+import "testing"
+
+func TestValidationReportAdd(t *testing.T) {
+	report := &ValidationReport{MaxRowErrors: 3}
+	report.Add() // A row without errors is not invalid
+	report.Add(RowError{Line: 2, Column: "Date"}, RowError{Line: 2, Column: "Amount"})
+	report.Add(RowError{Line: 3, Column: "Date"})
+	report.Add(RowError{Line: 4, Column: "Date"}) // Past the limit
+	report.Add(RowError{Line: 5, Column: "Date"})
+
+	if got := len(report.RowErrors); got != 3 {
+		t.Errorf("kept %d row errors, want 3", got)
+	}
+	if report.OmittedRows != 2 {
+		t.Errorf("OmittedRows = %d, want 2", report.OmittedRows)
+	}
+	if got := report.InvalidRows(); got != 4 {
+		t.Errorf("InvalidRows() = %d, want 4", got)
+	}
+	if !report.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+}
+
+func TestValidationReportOmittedRowsOnly(t *testing.T) {
+	report := &ValidationReport{OmittedRows: 1}
+	if !report.HasErrors() || report.InvalidRows() != 1 {
+		t.Errorf("HasErrors() = %v, InvalidRows() = %d, want true and 1", report.HasErrors(), report.InvalidRows())
+	}
+}
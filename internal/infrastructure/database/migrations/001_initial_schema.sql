@@ -0,0 +1,17 @@
+-- Initial schema for the transactions summary database.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id             VARCHAR(255) NOT NULL PRIMARY KEY,
+    debit_balance  FLOAT        NOT NULL DEFAULT 0,
+    credit_balance FLOAT        NOT NULL DEFAULT 0,
+    email          VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id               VARCHAR(255)             NOT NULL PRIMARY KEY,
+    account_id       VARCHAR(255)             NOT NULL,
+    amount           FLOAT                    NOT NULL,
+    transaction_date DATE                     NOT NULL,
+    type             ENUM('debit', 'credit')  NOT NULL,
+    FOREIGN KEY (account_id) REFERENCES accounts (id)
+);
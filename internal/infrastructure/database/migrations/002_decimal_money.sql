@@ -0,0 +1,15 @@
+-- Store amounts and balances as exact decimals instead of FLOAT.
+-- ROUND keeps the cents that FLOAT can only approximate.
+
+UPDATE accounts
+SET debit_balance  = ROUND(debit_balance, 2),
+    credit_balance = ROUND(credit_balance, 2);
+
+ALTER TABLE accounts
+    MODIFY debit_balance  DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    MODIFY credit_balance DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+UPDATE transactions SET amount = ROUND(amount, 2);
+
+ALTER TABLE transactions
+    MODIFY amount DECIMAL(15, 2) NOT NULL;
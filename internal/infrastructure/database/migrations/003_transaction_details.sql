@@ -0,0 +1,5 @@
+-- Keep the optional description and merchant of every transaction.
+
+ALTER TABLE transactions
+    ADD COLUMN description VARCHAR(255) NULL,
+    ADD COLUMN merchant    VARCHAR(255) NULL;
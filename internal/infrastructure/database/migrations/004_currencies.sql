@@ -0,0 +1,7 @@
+-- Record the currency of every transaction and the base currency of every account.
+
+ALTER TABLE accounts
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE transactions
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER amount;
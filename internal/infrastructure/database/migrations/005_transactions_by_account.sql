@@ -0,0 +1,3 @@
+-- Speed up reading an account's history for lifetime summaries.
+
+CREATE INDEX idx_transactions_account_date ON transactions (account_id, transaction_date);
@@ -0,0 +1,4 @@
+-- Record the language and region used for the emails of every account.
+
+ALTER TABLE accounts
+    ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT 'en-US';
@@ -0,0 +1,23 @@
+-- Remember the upload that stored every transaction, and keep the summary
+-- emails owed for each upload in an outbox written in the same transaction.
+
+ALTER TABLE transactions
+    ADD COLUMN upload_id VARCHAR(1024) NULL,
+    ADD INDEX idx_transactions_upload (account_id, upload_id(255));
+
+CREATE TABLE summary_outbox (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    upload_id VARCHAR(1024) NOT NULL,
+    account_id VARCHAR(255) NOT NULL,
+    status ENUM('pending', 'sent', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT NULL,
+    claim_token CHAR(36) NULL,
+    created_at DATETIME NOT NULL,
+    sent_at DATETIME NULL,
+    INDEX idx_summary_outbox_due (status, next_attempt_at),
+    INDEX idx_summary_outbox_account (account_id),
+    INDEX idx_summary_outbox_upload (upload_id(255)),
+    INDEX idx_summary_outbox_claim (claim_token)
+);
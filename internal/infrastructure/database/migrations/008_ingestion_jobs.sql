@@ -0,0 +1,21 @@
+-- Record every processing of an uploaded file, so uploaders can check whether
+-- their file was handled.
+
+CREATE TABLE ingestion_jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    source VARCHAR(1024) NOT NULL,
+    bucket VARCHAR(255) NULL,
+    object_key VARCHAR(1024) NOT NULL,
+    etag VARCHAR(255) NULL,
+    status ENUM('processing', 'processed', 'failed') NOT NULL DEFAULT 'processing',
+    rows_read INT NOT NULL DEFAULT 0,
+    rows_duplicate INT NOT NULL DEFAULT 0,
+    rows_inserted INT NOT NULL DEFAULT 0,
+    rows_rejected INT NOT NULL DEFAULT 0,
+    started_at DATETIME NOT NULL,
+    processed_at DATETIME NULL,
+    finished_at DATETIME NULL,
+    error TEXT NULL,
+    INDEX idx_ingestion_jobs_key (object_key(255)),
+    INDEX idx_ingestion_jobs_source (source(255))
+);
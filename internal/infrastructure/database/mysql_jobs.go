@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// Ensure MySQLTransactionRepo implements interfaces.IngestionJobRepository
+var _ interfaces.IngestionJobRepository = &MySQLTransactionRepo{}
+
+// jobColumns lists the columns read by scanJob, in order.
+const jobColumns = "id, source, COALESCE(bucket, ''), object_key, COALESCE(etag, ''), status, rows_read, rows_duplicate, rows_inserted, rows_rejected, started_at, processed_at, finished_at, COALESCE(error, '')"
+
+// CreateJob stores a new job and sets its ID.
+func (repo *MySQLTransactionRepo) CreateJob(ctx context.Context, job *entities.IngestionJob) error {
+	result, err := repo.DB.ExecContext(ctx,
+		"INSERT INTO ingestion_jobs (source, bucket, object_key, etag, status, started_at) VALUES (?, ?, ?, ?, ?, ?)",
+		job.Source, nullString(job.Bucket), job.Key, nullString(job.ETag), string(job.Status), formatDateTime(job.StartedAt),
+	)
+	if err != nil {
+		log.Printf("Error creating ingestion job: %v", err)
+		return fmt.Errorf("could not create ingestion job: %w", err)
+	}
+	if job.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("could not create ingestion job: %w", err)
+	}
+	return nil
+}
+
+// UpdateJob stores the status, row counts, timings and error of a job.
+func (repo *MySQLTransactionRepo) UpdateJob(ctx context.Context, job entities.IngestionJob) error {
+	_, err := repo.DB.ExecContext(ctx,
+		"UPDATE ingestion_jobs SET status = ?, rows_read = ?, rows_duplicate = ?, rows_inserted = ?, rows_rejected = ?, processed_at = ?, finished_at = ?, error = ? WHERE id = ?",
+		string(job.Status), job.RowsRead, job.RowsDuplicate, job.RowsInserted, job.RowsRejected,
+		nullDateTime(job.ProcessedAt), nullDateTime(job.FinishedAt), nullString(job.Error), job.ID,
+	)
+	if err != nil {
+		log.Printf("Error updating ingestion job %d: %v", job.ID, err)
+		return fmt.Errorf("could not update ingestion job: %w", err)
+	}
+	return nil
+}
+
+// FindJobs returns the jobs whose object key or source is key, newest first.
+func (repo *MySQLTransactionRepo) FindJobs(ctx context.Context, key string) ([]entities.IngestionJob, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT "+jobColumns+" FROM ingestion_jobs WHERE object_key = ? OR source = ? ORDER BY id DESC", key, key)
+	if err != nil {
+		log.Printf("Error finding ingestion jobs: %v", err)
+		return nil, fmt.Errorf("could not find ingestion jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []entities.IngestionJob
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not find ingestion jobs: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not find ingestion jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// scanJob scans a row selected with jobColumns into an ingestion job.
+func scanJob(row interface{ Scan(dest ...any) error }) (*entities.IngestionJob, error) {
+	job := &entities.IngestionJob{}
+	var status, startedAt string
+	var processedAt, finishedAt sql.NullString
+
+	err := row.Scan(&job.ID, &job.Source, &job.Bucket, &job.Key, &job.ETag, &status,
+		&job.RowsRead, &job.RowsDuplicate, &job.RowsInserted, &job.RowsRejected,
+		&startedAt, &processedAt, &finishedAt, &job.Error)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = entities.JobStatus(status)
+
+	if job.StartedAt, err = parseDateTime(startedAt); err != nil {
+		return nil, err
+	}
+	if processedAt.Valid {
+		if job.ProcessedAt, err = parseDateTime(processedAt.String); err != nil {
+			return nil, err
+		}
+	}
+	if finishedAt.Valid {
+		if job.FinishedAt, err = parseDateTime(finishedAt.String); err != nil {
+			return nil, err
+		}
+	}
+	return job, nil
+}
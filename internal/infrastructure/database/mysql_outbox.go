@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+
+	"github.com/google/uuid"
+)
+
+// Ensure MySQLTransactionRepo implements interfaces.OutboxRepository
+var _ interfaces.OutboxRepository = &MySQLTransactionRepo{}
+
+// dateTimeLayout is the format of DATETIME values. Times are stored in UTC.
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// outboxColumns lists the columns read by scanOutboxEntry, in order.
+const outboxColumns = "id, upload_id, account_id, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, sent_at"
+
+// insertOutbox adds the outbox entries with a single multi-row INSERT.
+func insertOutbox(ctx context.Context, tx *sql.Tx, outbox []entities.OutboxEntry) error {
+	if len(outbox) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(outbox))
+	args := make([]any, 0, len(outbox)*5)
+	for _, entry := range outbox {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?)")
+		args = append(args, entry.UploadID, entry.AccountID, string(entry.Status), formatDateTime(entry.NextAttemptAt), formatDateTime(entry.CreatedAt))
+	}
+
+	query := "INSERT INTO summary_outbox (upload_id, account_id, status, next_attempt_at, created_at) VALUES " + strings.Join(placeholders, ", ")
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ClaimOutbox leases up to limit due pending entries, of uploadID when it is
+// set, by moving their next attempt past the lease and tagging them with a
+// claim token.
+func (repo *MySQLTransactionRepo) ClaimOutbox(ctx context.Context, uploadID string, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEntry, error) {
+	token := uuid.NewString()
+	query := "UPDATE summary_outbox SET claim_token = ?, next_attempt_at = ? WHERE status = 'pending' AND next_attempt_at <= ?"
+	args := []any{token, formatDateTime(now.Add(lease)), formatDateTime(now)}
+	if uploadID != "" {
+		query += " AND upload_id = ?"
+		args = append(args, uploadID)
+	}
+	_, err := repo.DB.ExecContext(ctx, query+" ORDER BY id LIMIT ?", append(args, limit)...)
+	if err != nil {
+		log.Printf("Error claiming outbox entries: %v", err)
+		return nil, fmt.Errorf("could not claim outbox entries: %w", err)
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, "SELECT "+outboxColumns+" FROM summary_outbox WHERE claim_token = ? ORDER BY id", token)
+	if err != nil {
+		return nil, fmt.Errorf("could not read claimed outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entities.OutboxEntry
+	for rows.Next() {
+		entry, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not read claimed outbox entries: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not read claimed outbox entries: %w", err)
+	}
+	return entries, nil
+}
+
+// UpdateOutbox stores the delivery state of an entry and releases its lease.
+func (repo *MySQLTransactionRepo) UpdateOutbox(ctx context.Context, entry entities.OutboxEntry) error {
+	_, err := repo.DB.ExecContext(ctx,
+		"UPDATE summary_outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, sent_at = ?, claim_token = NULL WHERE id = ?",
+		string(entry.Status), entry.Attempts, formatDateTime(entry.NextAttemptAt), nullString(entry.LastError), nullDateTime(entry.SentAt), entry.ID,
+	)
+	if err != nil {
+		log.Printf("Error updating outbox entry %d: %v", entry.ID, err)
+		return fmt.Errorf("could not update outbox entry: %w", err)
+	}
+	return nil
+}
+
+// RequeueOutbox makes the matching entries due again with no attempts.
+func (repo *MySQLTransactionRepo) RequeueOutbox(ctx context.Context, accountId string, uploadID string, now time.Time) (int, error) {
+	query := "UPDATE summary_outbox SET status = 'pending', attempts = 0, next_attempt_at = ?, last_error = NULL, claim_token = NULL WHERE 1 = 1"
+	args := []any{formatDateTime(now)}
+	if accountId != "" {
+		query += " AND account_id = ?"
+		args = append(args, accountId)
+	}
+	if uploadID != "" {
+		query += " AND upload_id = ?"
+		args = append(args, uploadID)
+	}
+
+	result, err := repo.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error requeuing outbox entries: %v", err)
+		return 0, fmt.Errorf("could not requeue outbox entries: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not requeue outbox entries: %w", err)
+	}
+	return int(count), nil
+}
+
+// ListOutbox returns the entries of an upload ordered by account ID.
+func (repo *MySQLTransactionRepo) ListOutbox(ctx context.Context, uploadID string) ([]entities.OutboxEntry, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT "+outboxColumns+" FROM summary_outbox WHERE upload_id = ? ORDER BY account_id, id", uploadID)
+	if err != nil {
+		log.Printf("Error listing outbox entries: %v", err)
+		return nil, fmt.Errorf("could not list outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entities.OutboxEntry
+	for rows.Next() {
+		entry, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not list outbox entries: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not list outbox entries: %w", err)
+	}
+	return entries, nil
+}
+
+// scanOutboxEntry scans a row selected with outboxColumns into an outbox entry.
+func scanOutboxEntry(row interface{ Scan(dest ...any) error }) (*entities.OutboxEntry, error) {
+	entry := &entities.OutboxEntry{}
+	var status, nextAttemptAt, createdAt string
+	var sentAt sql.NullString
+
+	err := row.Scan(&entry.ID, &entry.UploadID, &entry.AccountID, &status, &entry.Attempts, &nextAttemptAt, &entry.LastError, &createdAt, &sentAt)
+	if err != nil {
+		return nil, err
+	}
+	entry.Status = entities.OutboxStatus(status)
+
+	if entry.NextAttemptAt, err = parseDateTime(nextAttemptAt); err != nil {
+		return nil, err
+	}
+	if entry.CreatedAt, err = parseDateTime(createdAt); err != nil {
+		return nil, err
+	}
+	if sentAt.Valid {
+		if entry.SentAt, err = parseDateTime(sentAt.String); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+// nullDateTime formats t, or returns NULL for the zero time.
+func nullDateTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatDateTime(t), Valid: true}
+}
+
+func parseDateTime(value string) (time.Time, error) {
+	t, err := time.Parse(dateTimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse date and time: %w", err)
+	}
+	return t, nil
+}
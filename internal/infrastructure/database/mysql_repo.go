@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"transactions-summary/internal/entities"
@@ -26,78 +29,530 @@ func NewMySQLTransactionRepo(db *sql.DB) *MySQLTransactionRepo {
 }
 
 // SaveTransaction saves a new transaction to the database.
-func (repo *MySQLTransactionRepo) SaveTransaction(transaction entities.Transaction) error {
-	_, err := repo.DB.Exec(
-		"INSERT INTO transactions (id, account_id, amount, transaction_date, type) VALUES (?, ?, ?, ?, ?)",
-		transaction.ID, transaction.AccountID, transaction.Amount, transaction.TransactionDate, transaction.Type,
+func (repo *MySQLTransactionRepo) SaveTransaction(ctx context.Context, transaction entities.Transaction) error {
+	_, err := repo.DB.ExecContext(ctx,
+		"INSERT INTO transactions (id, account_id, amount, currency, transaction_date, type, description, merchant, upload_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		transaction.ID, transaction.AccountID, transaction.Amount.String(), transaction.Amount.Currency, transaction.TransactionDate, transaction.Type,
+		nullString(transaction.Description), nullString(transaction.Merchant), nullString(transaction.UploadID),
 	)
 	if err != nil {
 		log.Printf("Error saving transaction %s: %v", transaction.ID, err)
-		return fmt.Errorf("could not save transaction: %v", err)
+		return fmt.Errorf("could not save transaction: %w", err)
 	}
 	log.Printf("Transaction %s saved successfully", transaction.ID)
 	return nil
 }
 
-// GetTransaction retrieves a transaction from the database by ID.
-func (repo *MySQLTransactionRepo) GetTransaction(transactionID string) (*entities.Transaction, error) {
-	query := "SELECT id, account_id, amount, transaction_date, type FROM transactions WHERE id = ?"
+// insertBatchSize is the number of rows per multi-row INSERT. It keeps each
+// statement well below MySQL's limit of 65535 placeholders.
+const insertBatchSize = 500
 
-	// Create a variable to hold the account details
-	transaction := &entities.Transaction{}
-	var dateString string
+// lookupBatchSize is the number of IDs per "IN (...)" lookup query.
+const lookupBatchSize = 1000
 
-	// Execute the query and scan the result into the account struct
-	err := repo.DB.QueryRow(query, transactionID).Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &dateString, &transaction.Type)
+// BeginUpload starts a SQL transaction that stores the transactions of an
+// upload chunk by chunk. Chunks are saved with multi-row INSERTs and added to
+// the account balances and the outbox in the same transaction, so either the
+// whole upload is stored or none of it.
+func (repo *MySQLTransactionRepo) BeginUpload(ctx context.Context) (interfaces.UploadWriter, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	return &mysqlUploadWriter{tx: tx}, nil
+}
+
+// mysqlUploadWriter implements the UploadWriter interface over a SQL transaction.
+type mysqlUploadWriter struct {
+	tx    *sql.Tx
+	saved int
+}
+
+// GetExistingTransactions returns the transactions of ids that are already
+// stored, including the ones saved earlier in the transaction.
+func (w *mysqlUploadWriter) GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error) {
+	return existingTransactions(ctx, w.tx, ids)
+}
+
+// SaveTransactions inserts a chunk of transactions and updates the account balances.
+func (w *mysqlUploadWriter) SaveTransactions(ctx context.Context, transactions []entities.Transaction) error {
+	for start := 0; start < len(transactions); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(transactions))
+		if err := insertTransactions(ctx, w.tx, transactions[start:end]); err != nil {
+			log.Printf("Error saving transactions: %v", err)
+			return fmt.Errorf("could not save transactions: %w", err)
+		}
+	}
+
+	if err := updateBalances(ctx, w.tx, transactions); err != nil {
+		log.Printf("Error updating account balances: %v", err)
+		return fmt.Errorf("could not update account balances: %w", err)
+	}
+	w.saved += len(transactions)
+	return nil
+}
+
+// Commit enqueues the outbox entries and commits the transaction.
+func (w *mysqlUploadWriter) Commit(ctx context.Context, outbox []entities.OutboxEntry) error {
+	if err := insertOutbox(ctx, w.tx, outbox); err != nil {
+		log.Printf("Error enqueuing summary emails: %v", err)
+		return fmt.Errorf("could not enqueue summary emails: %w", err)
+	}
+
+	if err := w.tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transactions: %w", err)
+	}
+	log.Printf("%d transactions saved successfully", w.saved)
+	return nil
+}
+
+// Rollback rolls the transaction back unless it was committed.
+func (w *mysqlUploadWriter) Rollback() error {
+	if err := w.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		return fmt.Errorf("could not roll back transactions: %w", err)
+	}
+	return nil
+}
+
+// insertTransactions inserts a batch of transactions with a single multi-row INSERT.
+func insertTransactions(ctx context.Context, tx *sql.Tx, transactions []entities.Transaction) error {
+	placeholders := make([]string, 0, len(transactions))
+	args := make([]any, 0, len(transactions)*9)
+	for _, transaction := range transactions {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			transaction.ID, transaction.AccountID, transaction.Amount.String(), transaction.Amount.Currency, transaction.TransactionDate, transaction.Type,
+			nullString(transaction.Description), nullString(transaction.Merchant), nullString(transaction.UploadID),
+		)
+	}
+
+	query := "INSERT INTO transactions (id, account_id, amount, currency, transaction_date, type, description, merchant, upload_id) VALUES " + strings.Join(placeholders, ", ")
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// balanceKey identifies the balances changed by a batch: an account and a currency.
+type balanceKey struct {
+	accountID string
+	currency  string
+}
+
+// updateBalances adds the debits and credits of the transactions to the
+// account balances. Balances are kept in the account currency, so rows in any
+// other currency are left out of them; they are logged for every account so
+// reconcile and the summaries can be checked against them.
+func updateBalances(ctx context.Context, tx *sql.Tx, transactions []entities.Transaction) error {
+	changes := make(map[balanceKey]*entities.Account)
+	counts := make(map[balanceKey]int)
+	var keys []balanceKey
+	var accountIDs []any
+	for _, transaction := range transactions {
+		key := balanceKey{accountID: transaction.AccountID, currency: transaction.Amount.Currency}
+		if _, exists := changes[key]; !exists {
+			changes[key] = &entities.Account{
+				DebitBalance:  entities.NewMoney(0, key.currency),
+				CreditBalance: entities.NewMoney(0, key.currency),
+				Currency:      key.currency,
+			}
+			keys = append(keys, key)
+			if !slices.Contains(accountIDs, any(key.accountID)) {
+				accountIDs = append(accountIDs, key.accountID)
+			}
+		}
+		changes[key].ApplyTransaction(transaction)
+		counts[key]++
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Lock the accounts until commit, so reconcile does not overwrite the new balances
+	currencies := make(map[string]string, len(accountIDs))
+	rows, err := tx.QueryContext(ctx, "SELECT id, currency FROM accounts WHERE id IN (?"+strings.Repeat(", ?", len(accountIDs)-1)+") FOR UPDATE", accountIDs...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, currency string
+		if err := rows.Scan(&id, &currency); err != nil {
+			rows.Close()
+			return err
+		}
+		currencies[id] = currency
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if currency, exists := currencies[key.accountID]; exists && currency != key.currency {
+			log.Printf("Account %s balances are kept in %s: %d transactions in %s are left out of them", key.accountID, currency, counts[key], key.currency)
+			continue
+		}
+		change := changes[key]
+		_, err := tx.ExecContext(ctx,
+			"UPDATE accounts SET debit_balance = debit_balance + ?, credit_balance = credit_balance + ? WHERE id = ? AND currency = ?",
+			change.DebitBalance.String(), change.CreditBalance.String(), key.accountID, key.currency,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transactionColumns are the columns read by scanTransaction, in order.
+const transactionColumns = "id, account_id, amount, currency, transaction_date, type, COALESCE(description, ''), COALESCE(merchant, ''), COALESCE(upload_id, '')"
+
+// GetExistingTransactions returns the transactions of ids that are already
+// stored, looking them up in batches instead of one query per ID.
+func (repo *MySQLTransactionRepo) GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error) {
+	return existingTransactions(ctx, repo.DB, ids)
+}
+
+// queryer runs queries on a database or inside a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// existingTransactions looks up which ids are stored, in batches of lookupBatchSize.
+func existingTransactions(ctx context.Context, q queryer, ids []string) (map[string]entities.Transaction, error) {
+	existing := make(map[string]entities.Transaction)
+
+	for start := 0; start < len(ids); start += lookupBatchSize {
+		batch := ids[start:min(start+lookupBatchSize, len(ids))]
+		query := "SELECT " + transactionColumns + " FROM transactions WHERE id IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+
+		rows, err := q.QueryContext(ctx, query, stringArgs(batch)...)
+		if err != nil {
+			log.Printf("Error looking up transactions: %v", err)
+			return nil, fmt.Errorf("could not look up transactions: %w", err)
+		}
+		for rows.Next() {
+			transaction, err := scanTransaction(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("could not look up transactions: %w", err)
+			}
+			existing[transaction.ID] = *transaction
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not look up transactions: %w", err)
+		}
+	}
+
+	return existing, nil
+}
+
+// GetExistingAccountIDs returns the subset of accountIds that are registered,
+// looking them up in batches.
+func (repo *MySQLTransactionRepo) GetExistingAccountIDs(ctx context.Context, accountIds []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+
+	for start := 0; start < len(accountIds); start += lookupBatchSize {
+		batch := accountIds[start:min(start+lookupBatchSize, len(accountIds))]
+		query := "SELECT id FROM accounts WHERE id IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+
+		rows, err := repo.DB.QueryContext(ctx, query, stringArgs(batch)...)
+		if err != nil {
+			log.Printf("Error looking up accounts: %v", err)
+			return nil, fmt.Errorf("could not look up accounts: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("could not look up accounts: %w", err)
+			}
+			existing[id] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not look up accounts: %w", err)
+		}
+	}
+
+	return existing, nil
+}
+
+// stringArgs converts query arguments to the type expected by database/sql.
+func stringArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// GetTransaction retrieves a transaction from the database by ID.
+func (repo *MySQLTransactionRepo) GetTransaction(ctx context.Context, transactionID string) (*entities.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = ?"
+
+	// Execute the query and scan the result into the transaction struct
+	transaction, err := scanTransaction(repo.DB.QueryRowContext(ctx, query, transactionID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Transaction with ID %s not found", transactionID)
 			return nil, fmt.Errorf("transaction with id %s not found", transactionID)
 		}
 		log.Printf("Error retrieving transaction %s: %v", transactionID, err)
-		return nil, fmt.Errorf("could not retrieve account: %v", err)
+		return nil, fmt.Errorf("could not retrieve transaction: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// ListTransactionsByAccount retrieves the transactions of an account between
+// from and to (inclusive), ordered by date. A zero from or to leaves that end open.
+func (repo *MySQLTransactionRepo) ListTransactionsByAccount(ctx context.Context, accountId string, from, to time.Time) ([]entities.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE account_id = ?"
+	args := []any{accountId}
+	if !from.IsZero() {
+		query += " AND transaction_date >= ?"
+		args = append(args, from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		query += " AND transaction_date <= ?"
+		args = append(args, to.Format("2006-01-02"))
+	}
+	query += " ORDER BY transaction_date, id"
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error listing transactions of account %s: %v", accountId, err)
+		return nil, fmt.Errorf("could not list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []entities.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not list transactions: %w", err)
+		}
+		transactions = append(transactions, *transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not list transactions: %w", err)
+	}
+
+	log.Printf("Retrieved %d transactions of account %s", len(transactions), accountId)
+	return transactions, nil
+}
+
+// ListTransactionsByUpload retrieves the transactions an upload stored for an
+// account, ordered by date.
+func (repo *MySQLTransactionRepo) ListTransactionsByUpload(ctx context.Context, accountId string, uploadID string) ([]entities.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE account_id = ? AND upload_id = ? ORDER BY transaction_date, id"
+
+	rows, err := repo.DB.QueryContext(ctx, query, accountId, uploadID)
+	if err != nil {
+		log.Printf("Error listing transactions of account %s in %s: %v", accountId, uploadID, err)
+		return nil, fmt.Errorf("could not list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []entities.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("could not list transactions: %w", err)
+		}
+		transactions = append(transactions, *transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not list transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// scanTransaction reads the transactionColumns of a row.
+func scanTransaction(row interface{ Scan(dest ...any) error }) (*entities.Transaction, error) {
+	transaction := &entities.Transaction{}
+	var amountString, currency, dateString string
+
+	err := row.Scan(&transaction.ID, &transaction.AccountID, &amountString, &currency, &dateString, &transaction.Type, &transaction.Description, &transaction.Merchant, &transaction.UploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the DECIMAL amount to Money
+	transaction.Amount, err = entities.ParseMoney(amountString, currency)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse amount: %w", err)
 	}
 
 	// Convert the dateString to time.Time
 	transaction.TransactionDate, err = time.Parse("2006-01-02", dateString)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse date: %v", err)
+		return nil, fmt.Errorf("could not parse date: %w", err)
 	}
 
 	return transaction, nil
 }
 
 // GetAccount retrieves an account from the database by ID.
-func (repo *MySQLTransactionRepo) GetAccount(id string) (*entities.Account, error) {
-	query := "SELECT id, debit_balance, credit_balance, email FROM accounts WHERE id = ?"
+func (repo *MySQLTransactionRepo) GetAccount(ctx context.Context, id string) (*entities.Account, error) {
+	query := "SELECT id, debit_balance, credit_balance, email, currency, locale FROM accounts WHERE id = ?"
 
 	// Create a variable to hold the account details
 	account := &entities.Account{}
+	var debitString, creditString string
 
 	// Execute the query and scan the result into the account struct
-	err := repo.DB.QueryRow(query, id).Scan(&account.ID, &account.DebitBalance, &account.CreditBalance, &account.Email)
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(&account.ID, &debitString, &creditString, &account.Email, &account.Currency, &account.Locale)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Account with ID %s not found", id)
-			return nil, fmt.Errorf("account with id %s not found", id)
+			return nil, fmt.Errorf("%w: %s", entities.ErrAccountNotFound, id)
 		}
 		log.Printf("Error retrieving account %s: %v", id, err)
-		return nil, fmt.Errorf("could not retrieve account: %v", err)
+		return nil, fmt.Errorf("could not retrieve account: %w", err)
+	}
+
+	// Convert the DECIMAL balances to Money
+	if account.DebitBalance, err = entities.ParseMoney(debitString, account.Currency); err != nil {
+		return nil, fmt.Errorf("could not parse debit balance: %w", err)
+	}
+	if account.CreditBalance, err = entities.ParseMoney(creditString, account.Currency); err != nil {
+		return nil, fmt.Errorf("could not parse credit balance: %w", err)
 	}
 
 	log.Printf("Account %s retrieved successfully", id)
 	return account, nil
 }
 
+// GetAccounts retrieves all accounts from the database, ordered by ID.
+func (repo *MySQLTransactionRepo) GetAccounts(ctx context.Context) ([]entities.Account, error) {
+	rows, err := repo.DB.QueryContext(ctx, "SELECT id, debit_balance, credit_balance, email, currency, locale FROM accounts ORDER BY id")
+	if err != nil {
+		log.Printf("Error retrieving accounts: %v", err)
+		return nil, fmt.Errorf("could not retrieve accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []entities.Account
+	for rows.Next() {
+		var account entities.Account
+		var debitString, creditString string
+		if err := rows.Scan(&account.ID, &debitString, &creditString, &account.Email, &account.Currency, &account.Locale); err != nil {
+			return nil, fmt.Errorf("could not retrieve accounts: %w", err)
+		}
+		if account.DebitBalance, err = entities.ParseMoney(debitString, account.Currency); err != nil {
+			return nil, fmt.Errorf("could not parse debit balance: %w", err)
+		}
+		if account.CreditBalance, err = entities.ParseMoney(creditString, account.Currency); err != nil {
+			return nil, fmt.Errorf("could not parse credit balance: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not retrieve accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// GetTransactionTotals sums the stored debits and credits of an account in one currency.
+func (repo *MySQLTransactionRepo) GetTransactionTotals(ctx context.Context, accountId string, currency string) (entities.Money, entities.Money, error) {
+	return transactionTotals(ctx, repo.DB, accountId, currency)
+}
+
+// queryRower runs single-row queries on a database or inside a transaction.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// transactionTotals sums the debits and credits of an account in one currency.
+func transactionTotals(ctx context.Context, q queryRower, accountId string, currency string) (entities.Money, entities.Money, error) {
+	query := `SELECT
+		COALESCE(SUM(CASE WHEN type = 'debit' THEN amount END), 0),
+		COALESCE(SUM(CASE WHEN type = 'credit' THEN amount END), 0)
+		FROM transactions WHERE account_id = ? AND currency = ?`
+
+	var debitString, creditString string
+	if err := q.QueryRowContext(ctx, query, accountId, currency).Scan(&debitString, &creditString); err != nil {
+		log.Printf("Error totaling transactions of account %s: %v", accountId, err)
+		return entities.Money{}, entities.Money{}, fmt.Errorf("could not total transactions: %w", err)
+	}
+
+	debit, err := entities.ParseMoney(debitString, currency)
+	if err != nil {
+		return entities.Money{}, entities.Money{}, fmt.Errorf("could not parse debit total: %w", err)
+	}
+	credit, err := entities.ParseMoney(creditString, currency)
+	if err != nil {
+		return entities.Money{}, entities.Money{}, fmt.Errorf("could not parse credit total: %w", err)
+	}
+	return debit, credit, nil
+}
+
+// RecomputeBalances overwrites the balances of an account with the totals of
+// its transactions in the account currency. The account row stays locked from
+// the read to the update, so an upload committing meanwhile waits and then
+// adds its amounts to the recomputed balances.
+func (repo *MySQLTransactionRepo) RecomputeBalances(ctx context.Context, accountId string) (*entities.Account, *entities.Account, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stored := &entities.Account{ID: accountId}
+	var debitString, creditString string
+	err = tx.QueryRowContext(ctx, "SELECT debit_balance, credit_balance, email, currency FROM accounts WHERE id = ? FOR UPDATE", accountId).Scan(&debitString, &creditString, &stored.Email, &stored.Currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("%w: %s", entities.ErrAccountNotFound, accountId)
+		}
+		log.Printf("Error locking account %s: %v", accountId, err)
+		return nil, nil, fmt.Errorf("could not lock account: %w", err)
+	}
+	if stored.DebitBalance, err = entities.ParseMoney(debitString, stored.Currency); err != nil {
+		return nil, nil, fmt.Errorf("could not parse debit balance: %w", err)
+	}
+	if stored.CreditBalance, err = entities.ParseMoney(creditString, stored.Currency); err != nil {
+		return nil, nil, fmt.Errorf("could not parse credit balance: %w", err)
+	}
+
+	recomputed := *stored
+	recomputed.DebitBalance, recomputed.CreditBalance, err = transactionTotals(ctx, tx, accountId, stored.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE accounts SET debit_balance = ?, credit_balance = ? WHERE id = ?", recomputed.DebitBalance.String(), recomputed.CreditBalance.String(), accountId,
+	)
+	if err != nil {
+		log.Printf("Error updating account %s: %v", accountId, err)
+		return nil, nil, fmt.Errorf("could not update account: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("could not commit balances: %w", err)
+	}
+	return stored, &recomputed, nil
+}
+
 // UpdateAccount updates a given account from the database.
-func (repo *MySQLTransactionRepo) UpdateAccount(account *entities.Account) error {
-	_, err := repo.DB.Exec(
-		"UPDATE accounts SET debit_balance = ?, credit_balance = ? WHERE id = ?", account.DebitBalance, account.CreditBalance, account.ID,
+func (repo *MySQLTransactionRepo) UpdateAccount(ctx context.Context, account *entities.Account) error {
+	_, err := repo.DB.ExecContext(ctx,
+		"UPDATE accounts SET debit_balance = ?, credit_balance = ? WHERE id = ?", account.DebitBalance.String(), account.CreditBalance.String(), account.ID,
 	)
 	if err != nil {
 		log.Printf("Error updating account %s: %v", account.ID, err)
-		return fmt.Errorf("could not save transaction: %v", err)
+		return fmt.Errorf("could not save transaction: %w", err)
 	}
 	log.Printf("Account %s updated successfully", account.ID)
 	return nil
 }
+
+// nullString stores empty optional text columns as NULL.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,14 @@
+package email
+
+import "strings"
+
+// ParseAddresses splits a comma-separated list of addresses, dropping blanks.
+func ParseAddresses(value string) []string {
+	var addresses []string
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,97 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/file"
+	"transactions-summary/internal/interfaces"
+)
+
+// ErrorReportSender implements the ErrorReporter interface by emailing the
+// validation report to an operator.
+type ErrorReportSender struct {
+	EmailSender interfaces.EmailSender
+	To          string
+}
+
+// Ensure ErrorReportSender implements interfaces.ErrorReporter
+var _ interfaces.ErrorReporter = &ErrorReportSender{}
+
+// NewErrorReportSender creates a new ErrorReportSender that emails reports to the operator address.
+func NewErrorReportSender(sender interfaces.EmailSender, to string) *ErrorReportSender {
+	return &ErrorReportSender{
+		EmailSender: sender,
+		To:          to,
+	}
+}
+
+// ReportErrors emails the line, column and reason of every invalid value.
+func (r *ErrorReportSender) ReportErrors(ctx context.Context, report *entities.ValidationReport) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<p>%d invalid rows in %s</p>", report.InvalidRows(), html.EscapeString(report.Source)))
+	sb.WriteString(`<table border="1" cellpadding="4" style="border-collapse: collapse;">`)
+	// Reports of archives name the file of each row
+	withFile := slices.ContainsFunc(report.RowErrors, func(rowError entities.RowError) bool {
+		return rowError.File != ""
+	})
+	sb.WriteString("<tr>")
+	if withFile {
+		sb.WriteString("<th>File</th>")
+	}
+	sb.WriteString("<th>Line</th><th>Column</th><th>Value</th><th>Reason</th></tr>")
+	for _, rowError := range report.RowErrors {
+		sb.WriteString("<tr>")
+		if withFile {
+			sb.WriteString(fmt.Sprintf("<td>%s</td>", html.EscapeString(rowError.File)))
+		}
+		sb.WriteString(fmt.Sprintf("<td>%d</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+			rowError.Line,
+			html.EscapeString(rowError.Column),
+			html.EscapeString(rowError.Value),
+			html.EscapeString(rowError.Reason)))
+	}
+	sb.WriteString("</table>")
+	if report.OmittedRows > 0 {
+		sb.WriteString(fmt.Sprintf("<p>%d more invalid rows not listed</p>", report.OmittedRows))
+	}
+
+	var text strings.Builder
+	if err := file.WriteErrorReport(&text, report); err != nil {
+		return err
+	}
+
+	message := entities.EmailMessage{
+		To:       r.To,
+		Subject:  "Validation errors in " + report.Source,
+		HTMLBody: sb.String(),
+		TextBody: text.String(),
+	}
+	if err := r.EmailSender.SendEmail(ctx, message); err != nil {
+		return fmt.Errorf("could not send error report: %w", err)
+	}
+	return nil
+}
+
+// Quarantine emails the raw invalid rows so they can be fixed and uploaded again.
+func (r *ErrorReportSender) Quarantine(ctx context.Context, report *entities.ValidationReport) error {
+	var sb strings.Builder
+	if err := file.WriteQuarantine(&sb, report); err != nil {
+		return err
+	}
+
+	message := entities.EmailMessage{
+		To:       r.To,
+		Subject:  "Quarantined rows from " + report.Source,
+		HTMLBody: "<pre>" + html.EscapeString(sb.String()) + "</pre>",
+		TextBody: sb.String(),
+	}
+	if err := r.EmailSender.SendEmail(ctx, message); err != nil {
+		return fmt.Errorf("could not send quarantined rows: %w", err)
+	}
+	return nil
+}
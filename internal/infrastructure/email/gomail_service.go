@@ -1,19 +1,38 @@
 package email
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"transactions-summary/internal/entities"
 
 	"gopkg.in/gomail.v2"
 )
 
 // GomailService implements the EmailSender interface using the gomail library.
+//
+// By default every email opens its own SMTP connection. During a session
+// the service keeps one connection for all emails and reconnects when it fails.
+// RateLimit caps the messages sent per second in both modes; zero means no limit.
 type GomailService struct {
-	SMTPHost string
-	SMTPPort int
-	Username string
-	Password string
-	From     string
+	SMTPHost  string
+	SMTPPort  int
+	Username  string
+	Password  string
+	From      string
+	RateLimit float64
+
+	mu       sync.Mutex
+	session  bool
+	sender   *smtpConn
+	lastSent time.Time
 }
 
 // NewGomailService creates a new instance of GomailService.
@@ -27,19 +46,205 @@ func NewGomailService(smtpHost string, smtpPort int, username, password, from st
 	}
 }
 
-// SendEmail sends an email using SMTP.
-func (s *GomailService) SendEmail(to string, subject string, body string) error {
+// SendEmail sends an email using SMTP. When both bodies are set the message is
+// multipart/alternative, with the HTML body preferred by capable clients, and
+// attachments make it multipart/mixed.
+func (s *GomailService) SendEmail(ctx context.Context, email entities.EmailMessage) error {
 	message := gomail.NewMessage()
 	message.SetHeader("From", s.From)
-	message.SetHeader("To", to)
-	message.SetHeader("Subject", subject)
-	message.SetBody("text/html", body) // HTML body for styled emails
+	message.SetHeader("To", email.To)
+	if len(email.Cc) > 0 {
+		message.SetHeader("Cc", email.Cc...)
+	}
+	if len(email.Bcc) > 0 {
+		message.SetHeader("Bcc", email.Bcc...)
+	}
+	if email.ReplyTo != "" {
+		message.SetHeader("Reply-To", email.ReplyTo)
+	}
+	message.SetHeader("Subject", email.Subject)
+	switch {
+	case email.TextBody != "" && email.HTMLBody != "":
+		message.SetBody("text/plain", email.TextBody)
+		message.AddAlternative("text/html", email.HTMLBody) // HTML body for styled emails
+	case email.TextBody != "":
+		message.SetBody("text/plain", email.TextBody)
+	default:
+		message.SetBody("text/html", email.HTMLBody)
+	}
+	for _, attachment := range email.Attachments {
+		data := attachment.Data
+		message.Attach(attachment.Filename,
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.wait(ctx); err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+
+	if err := s.send(ctx, message); err != nil {
+		log.Printf("Could not send email to %s: %v", email.To, err)
+		return fmt.Errorf("could not send email: %w", err)
+	}
+	log.Printf("Email sent successfully to %s", email.To)
+	return nil
+}
+
+// BeginSession makes the emails sent until EndSession share one SMTP
+// connection. The connection is opened by the first email.
+func (s *GomailService) BeginSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = true
+}
+
+// EndSession ends the session and closes its SMTP connection.
+func (s *GomailService) EndSession() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session = false
+	if s.sender == nil {
+		return nil
+	}
+	err := s.sender.Close()
+	s.sender = nil
+	if err != nil {
+		return fmt.Errorf("could not close SMTP connection: %w", err)
+	}
+	return nil
+}
+
+// send delivers a message over the session connection. Outside a session it
+// uses a new connection for the message. When the connection was dropped it
+// dials again and retries once; a message the server refuses is not retried.
+// Canceling ctx aborts the SMTP exchange through the connection deadline.
+func (s *GomailService) send(ctx context.Context, message *gomail.Message) error {
+	if !s.session {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		err = sendOn(ctx, conn, message)
+		if ctx.Err() != nil || connectionError(err) {
+			conn.Abort()
+		} else {
+			conn.Close()
+		}
+		return err
+	}
+
+	if s.sender == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.sender = conn
+	}
+	err := sendOn(ctx, s.sender, message)
+	if err != nil && ctx.Err() == nil && connectionError(err) {
+		log.Printf("SMTP connection failed, reconnecting: %v", err)
+		s.sender.Abort()
+		if s.sender, err = s.dial(ctx); err != nil {
+			return err
+		}
+		err = sendOn(ctx, s.sender, message)
+	}
+	s.settle(ctx, err)
+	return err
+}
+
+// settle keeps the session connection only while it can send the next
+// message. The caller must hold s.mu.
+func (s *GomailService) settle(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		return
+	case ctx.Err() != nil || connectionError(err):
+		// The exchange was cut short, so the connection state is unknown
+		s.sender.Abort()
+	default:
+		// The server refused this message, e.g. an unknown recipient: abort
+		// its mail transaction so the connection can send the next one
+		if s.sender.Reset() == nil {
+			return
+		}
+		s.sender.Close()
+	}
+	s.sender = nil
+}
 
-	dialer := gomail.NewDialer(s.SMTPHost, s.SMTPPort, s.Username, s.Password)
-	if err := dialer.DialAndSend(message); err != nil {
-		log.Printf("Could not send email to %s: %v", to, err)
-		return fmt.Errorf("could not send email: %v", err)
+// connectionError reports whether err means the SMTP connection is unusable,
+// so the message may be sent again over a new one: the server closed it, the
+// network failed or the server is shutting the channel down (421).
+func connectionError(err error) bool {
+	var netErr net.Error
+	var smtpErr *textproto.Error
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) ||
+		(errors.As(err, &smtpErr) && smtpErr.Code == 421)
+}
+
+// sendOn sends a message over conn, interrupting it when ctx is done. It
+// returns the error of the connection itself, which gomail.Send would flatten
+// into text, so connectionError can inspect it.
+func sendOn(ctx context.Context, conn *smtpConn, message *gomail.Message) error {
+	stop := conn.interrupt(ctx)
+	defer stop()
+
+	var sendErr error
+	err := gomail.Send(gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		sendErr = conn.Send(from, to, msg)
+		return sendErr
+	}), message)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case sendErr != nil:
+		return sendErr
+	}
+	return err
+}
+
+// dial opens a connection to the SMTP server.
+func (s *GomailService) dial(ctx context.Context) (*smtpConn, error) {
+	conn, err := dialSMTP(ctx, s.SMTPHost, s.SMTPPort, s.Username, s.Password)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("could not connect to SMTP server: %w", err)
+	}
+	log.Printf("Connected to SMTP server %s:%d", s.SMTPHost, s.SMTPPort)
+	return conn, nil
+}
+
+// wait sleeps until the next message is allowed by RateLimit or ctx is done.
+// The caller must hold s.mu, so concurrent senders are spaced out as well.
+func (s *GomailService) wait(ctx context.Context) error {
+	if s.RateLimit <= 0 {
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / s.RateLimit)
+	if delay := time.Until(s.lastSent.Add(interval)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	log.Printf("Email sent successfully to %s", to)
+	s.lastSent = time.Now()
 	return nil
 }
@@ -0,0 +1,216 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
+
+// fakeSMTPServer is a minimal SMTP server on localhost. It refuses the
+// recipients in refuse, never answers the end of the messages to the
+// recipients in hang, and drops the connection after dropAfter messages when
+// set.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	refuse    map[string]bool
+	hang      map[string]bool
+	dropAfter int
+
+	mu          sync.Mutex
+	connections int
+	delivered   []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ready")
+	sent, recipient := 0, ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			recipient = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			if s.refuse[recipient] {
+				reply("550 no such user")
+				continue
+			}
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 go ahead")
+			for {
+				data, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" {
+					break
+				}
+			}
+			if s.hang[recipient] {
+				// Keep the connection open without answering
+				io.Copy(io.Discard, r)
+				return
+			}
+			s.mu.Lock()
+			s.delivered = append(s.delivered, recipient)
+			s.mu.Unlock()
+			reply("250 queued")
+			sent++
+			if s.dropAfter > 0 && sent == s.dropAfter {
+				return
+			}
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			// MAIL FROM, RSET and NOOP
+			reply("250 OK")
+		}
+	}
+}
+
+// stats returns the connections accepted and the recipients delivered to.
+func (s *fakeSMTPServer) stats() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, append([]string(nil), s.delivered...)
+}
+
+// newTestService returns a session service connected to server.
+func newTestService(t *testing.T, server *fakeSMTPServer) *GomailService {
+	addr := server.listener.Addr().(*net.TCPAddr)
+	service := NewGomailService("127.0.0.1", addr.Port, "", "", "sender@example.com")
+	service.BeginSession()
+	t.Cleanup(func() { service.EndSession() })
+	return service
+}
+
+func testMessage(to string) entities.EmailMessage {
+	return entities.EmailMessage{To: to, Subject: "Your summary", TextBody: "summary"}
+}
+
+func TestGomailServiceSession(t *testing.T) {
+	tests := []struct {
+		name            string
+		refuse          map[string]bool
+		dropAfter       int
+		to              []string
+		wantErr         []bool
+		wantConnections int
+		wantDelivered   []string
+	}{
+		{
+			name:            "one connection for the session",
+			to:              []string{"one@example.com", "two@example.com"},
+			wantErr:         []bool{false, false},
+			wantConnections: 1,
+			wantDelivered:   []string{"one@example.com", "two@example.com"},
+		},
+		{
+			name:            "a refused message is not retried and keeps the connection",
+			refuse:          map[string]bool{"unknown@example.com": true},
+			to:              []string{"unknown@example.com", "two@example.com"},
+			wantErr:         []bool{true, false},
+			wantConnections: 1,
+			wantDelivered:   []string{"two@example.com"},
+		},
+		{
+			name:            "a dropped connection is reopened",
+			dropAfter:       1,
+			to:              []string{"one@example.com", "two@example.com"},
+			wantErr:         []bool{false, false},
+			wantConnections: 2,
+			wantDelivered:   []string{"one@example.com", "two@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			server.refuse = tt.refuse
+			server.dropAfter = tt.dropAfter
+			service := newTestService(t, server)
+
+			for i, to := range tt.to {
+				err := service.SendEmail(context.Background(), testMessage(to))
+				if (err != nil) != tt.wantErr[i] {
+					t.Errorf("SendEmail(%s) error = %v, want error %v", to, err, tt.wantErr[i])
+				}
+			}
+
+			connections, delivered := server.stats()
+			if connections != tt.wantConnections {
+				t.Errorf("%d connections, want %d", connections, tt.wantConnections)
+			}
+			if strings.Join(delivered, ",") != strings.Join(tt.wantDelivered, ",") {
+				t.Errorf("delivered to %v, want %v", delivered, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+func TestGomailServiceCanceled(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.hang = map[string]bool{"one@example.com": true}
+	service := newTestService(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := service.SendEmail(ctx, testMessage("one@example.com"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendEmail() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SendEmail() returned after %v, want it to stop at the deadline", elapsed)
+	}
+
+	// The interrupted connection is dropped and the next email dials again
+	if err := service.SendEmail(context.Background(), testMessage("two@example.com")); err != nil {
+		t.Fatalf("SendEmail() after a cancellation: %v", err)
+	}
+	if connections, _ := server.stats(); connections != 2 {
+		t.Errorf("%d connections, want 2", connections)
+	}
+}
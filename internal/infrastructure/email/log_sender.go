@@ -0,0 +1,52 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"transactions-summary/internal/entities"
+)
+
+// LogEmailSender implements the EmailSender interface by writing each email to
+// a writer instead of sending it. It is used for dry runs.
+type LogEmailSender struct {
+	mu  sync.Mutex
+	Out io.Writer
+}
+
+// NewLogEmailSender creates a new LogEmailSender that writes to out.
+func NewLogEmailSender(out io.Writer) *LogEmailSender {
+	return &LogEmailSender{Out: out}
+}
+
+// SendEmail writes the email to the configured writer, plain-text body first.
+// Attachments are listed by name and size rather than written out.
+func (s *LogEmailSender) SendEmail(ctx context.Context, message entities.EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var headers strings.Builder
+	fmt.Fprintf(&headers, "To: %s\n", message.To)
+	if len(message.Cc) > 0 {
+		fmt.Fprintf(&headers, "Cc: %s\n", strings.Join(message.Cc, ", "))
+	}
+	if len(message.Bcc) > 0 {
+		fmt.Fprintf(&headers, "Bcc: %s\n", strings.Join(message.Bcc, ", "))
+	}
+	if message.ReplyTo != "" {
+		fmt.Fprintf(&headers, "Reply-To: %s\n", message.ReplyTo)
+	}
+	fmt.Fprintf(&headers, "Subject: %s\n", message.Subject)
+	for _, attachment := range message.Attachments {
+		fmt.Fprintf(&headers, "Attachment: %s (%s, %d bytes)\n", attachment.Filename, attachment.ContentType, len(attachment.Data))
+	}
+
+	_, err := fmt.Fprintf(s.Out, "%s\n%s\n\n%s\n\n", headers.String(), message.TextBody, message.HTMLBody)
+	if err != nil {
+		return fmt.Errorf("could not write email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	// smtpDialTimeout bounds the TCP connection when the context has no earlier deadline.
+	smtpDialTimeout = 10 * time.Second
+	// smtpQuitTimeout bounds the QUIT command sent when a connection is closed.
+	smtpQuitTimeout = 5 * time.Second
+)
+
+// smtpConn is an authenticated SMTP connection. It implements gomail.SendCloser
+// so gomail builds the messages, while the connection itself honors contexts:
+// dialing stops at the context deadline and interrupt aborts blocked I/O.
+type smtpConn struct {
+	conn   net.Conn
+	client *smtp.Client
+}
+
+// Ensure smtpConn can send gomail messages
+var _ gomail.SendCloser = &smtpConn{}
+
+// dialSMTP connects to the server, upgrades to TLS (implicit on port 465,
+// STARTTLS otherwise when offered) and authenticates when a username is set.
+func dialSMTP(ctx context.Context, host string, port int, username, password string) (*smtpConn, error) {
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+	if port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c := &smtpConn{conn: conn}
+	stop := c.interrupt(ctx)
+	defer stop()
+
+	c.client, err = smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if port != 465 {
+		if ok, _ := c.client.Extension("STARTTLS"); ok {
+			if err := c.client.StartTLS(tlsConfig); err != nil {
+				c.client.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if username != "" {
+		if ok, mechanisms := c.client.Extension("AUTH"); ok {
+			if err := c.client.Auth(smtpAuth(mechanisms, host, username, password)); err != nil {
+				c.client.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.client.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// smtpAuth picks the strongest mechanism the server offers, like gomail does.
+func smtpAuth(mechanisms, host, username, password string) smtp.Auth {
+	switch {
+	case strings.Contains(mechanisms, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(username, password)
+	case strings.Contains(mechanisms, "LOGIN") && !strings.Contains(mechanisms, "PLAIN"):
+		return &loginAuth{username: username, password: password, host: host}
+	default:
+		return smtp.PlainAuth("", username, password, host)
+	}
+}
+
+// interrupt makes blocked reads and writes on the connection fail as soon as
+// ctx is done. The returned function stops watching ctx.
+func (c *smtpConn) interrupt(ctx context.Context) func() bool {
+	return context.AfterFunc(ctx, func() {
+		c.conn.SetDeadline(time.Now())
+	})
+}
+
+// Send delivers one message to the given recipients.
+func (c *smtpConn) Send(from string, to []string, msg io.WriterTo) error {
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := c.client.Rcpt(address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Reset aborts the current mail transaction and keeps the connection open.
+func (c *smtpConn) Reset() error {
+	return c.client.Reset()
+}
+
+// Close ends the SMTP session and closes the connection. QUIT is only given
+// smtpQuitTimeout, so a server that stopped answering cannot block Close.
+func (c *smtpConn) Close() error {
+	c.conn.SetDeadline(time.Now().Add(smtpQuitTimeout))
+	err := c.client.Quit()
+	c.conn.Close()
+	return err
+}
+
+// Abort closes the connection without QUIT, for an exchange that was cut
+// short by a canceled context or a network failure.
+func (c *smtpConn) Abort() {
+	c.conn.Close()
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		advertised := false
+		for _, mechanism := range server.Auth {
+			if mechanism == "LOGIN" {
+				advertised = true
+				break
+			}
+		}
+		if !advertised {
+			return "", nil, errors.New("unencrypted connection")
+		}
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch {
+	case bytes.Equal(fromServer, []byte("Username:")):
+		return []byte(a.username), nil
+	case bytes.Equal(fromServer, []byte("Password:")):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
@@ -0,0 +1,176 @@
+package emailtemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"transactions-summary/internal/entities"
+)
+
+// DefaultLocale is used for accounts without a locale, or with a locale that
+// has no catalog for its language.
+const DefaultLocale = "en-US"
+
+// catalog holds the translated messages and number formats of one locale.
+// Catalogs are JSON files named after the locale, e.g. "es-MX.json".
+type catalog struct {
+	Locale           string            `json:"locale"`
+	DecimalSeparator string            `json:"decimal_separator"`
+	GroupSeparator   string            `json:"group_separator"`
+	MoneyPattern     string            `json:"money_pattern"` // Placeholders: {sign} {symbol} {amount} {code}
+	Months           [12]string        `json:"months"`
+	Messages         map[string]string `json:"messages"`
+}
+
+// loadCatalogs reads the embedded catalogs and then the ones in dir/locales,
+// which override embedded catalogs of the same locale.
+func loadCatalogs(dir string) (map[string]*catalog, error) {
+	catalogs := make(map[string]*catalog)
+
+	embedded, err := fs.Glob(defaultTemplates, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range embedded {
+		data, err := defaultTemplates.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read default catalog %s: %w", name, err)
+		}
+		if err := addCatalog(catalogs, name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir != "" {
+		overrides, err := filepath.Glob(filepath.Join(dir, "locales", "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range overrides {
+			data, err := os.ReadFile(name)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("could not read catalog %s: %w", name, err)
+			}
+			if err := addCatalog(catalogs, name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, exists := catalogs[strings.ToLower(DefaultLocale)]; !exists {
+		return nil, fmt.Errorf("missing catalog for default locale %s", DefaultLocale)
+	}
+	return catalogs, nil
+}
+
+func addCatalog(catalogs map[string]*catalog, name string, data []byte) error {
+	c := &catalog{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("could not parse catalog %s: %w", name, err)
+	}
+	if c.Locale == "" {
+		c.Locale = strings.TrimSuffix(filepath.Base(name), ".json")
+	}
+	catalogs[strings.ToLower(c.Locale)] = c
+	return nil
+}
+
+// catalogFor picks the catalog of a locale: an exact match first, then any
+// catalog of the same language (e.g. "es-AR" uses "es-MX"), then the default.
+func catalogFor(catalogs map[string]*catalog, locale string) *catalog {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if c, exists := catalogs[locale]; exists {
+		return c
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	var match *catalog
+	for key, c := range catalogs {
+		if strings.HasPrefix(key, language+"-") || key == language {
+			// Pick the same catalog every time when several share the language
+			if match == nil || c.Locale < match.Locale {
+				match = c
+			}
+		}
+	}
+	if match != nil {
+		return match
+	}
+	return catalogs[strings.ToLower(DefaultLocale)]
+}
+
+// translate returns the message for key, falling back to the default catalog
+// and finally to the key itself.
+func (c *catalog) translate(fallback *catalog, key string) string {
+	if message, exists := c.Messages[key]; exists {
+		return message
+	}
+	if message, exists := fallback.Messages[key]; exists {
+		return message
+	}
+	return key
+}
+
+// monthName returns the localized name of a month number (1 to 12).
+func (c *catalog) monthName(month int) string {
+	if month < 1 || month > 12 || c.Months[month-1] == "" {
+		return fmt.Sprint(month)
+	}
+	return c.Months[month-1]
+}
+
+// currencySymbols maps currency codes to the symbol shown before amounts.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"MXN": "$",
+	"EUR": "€",
+}
+
+// formatMoney formats an amount with the catalog separators and money pattern,
+// e.g. "$1,060.50 USD" or "-$10.30 MXN".
+func (c *catalog) formatMoney(m entities.Money) string {
+	amount := m.String()
+	sign := ""
+	if m.IsNegative() {
+		sign = "-"
+		amount = amount[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	amount = groupDigits(whole, c.GroupSeparator) + c.DecimalSeparator + fraction
+
+	pattern := c.MoneyPattern
+	if pattern == "" {
+		pattern = "{sign}{symbol}{amount} {code}"
+	}
+	return strings.NewReplacer(
+		"{sign}", sign,
+		"{symbol}", currencySymbols[m.Currency],
+		"{amount}", amount,
+		"{code}", m.Currency,
+	).Replace(pattern)
+}
+
+// groupDigits inserts the separator between groups of three digits.
+func groupDigits(digits, separator string) string {
+	if separator == "" || len(digits) <= 3 {
+		return digits
+	}
+	var sb strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		sb.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
@@ -0,0 +1,163 @@
+package emailtemplate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"transactions-summary/internal/entities"
+)
+
+func TestCatalogFor(t *testing.T) {
+	catalogs, err := loadCatalogs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "es-MX", want: "es-MX"},
+		{locale: "ES_mx", want: "es-MX"},
+		{locale: "es-AR", want: "es-MX"},
+		{locale: "es", want: "es-MX"},
+		{locale: "fr-FR", want: "en-US"},
+		{locale: "", want: "en-US"},
+	}
+	for _, tt := range tests {
+		if got := catalogFor(catalogs, tt.locale).Locale; got != tt.want {
+			t.Errorf("catalogFor(%q) = %s, want %s", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	catalogs, err := loadCatalogs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback := catalogs["en-us"]
+
+	// Every embedded catalog translates every message and month of the default one
+	for _, c := range catalogs {
+		for key := range fallback.Messages {
+			if _, exists := c.Messages[key]; !exists {
+				t.Errorf("catalog %s has no message %q", c.Locale, key)
+			}
+		}
+		for i, month := range c.Months {
+			if month == "" {
+				t.Errorf("catalog %s has no name for month %d", c.Locale, i+1)
+			}
+		}
+	}
+}
+
+func TestCatalogTranslate(t *testing.T) {
+	fallback := &catalog{Messages: map[string]string{"title": "Summary", "balance": "Balance"}}
+	c := &catalog{Messages: map[string]string{"title": "Resumen"}}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "title", want: "Resumen"},
+		{key: "balance", want: "Balance"},
+		{key: "missing", want: "missing"},
+	}
+	for _, tt := range tests {
+		if got := c.translate(fallback, tt.key); got != tt.want {
+			t.Errorf("translate(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogMonthName(t *testing.T) {
+	c := &catalog{Months: [12]string{"enero", "febrero"}}
+
+	tests := []struct {
+		month int
+		want  string
+	}{
+		{month: 1, want: "enero"},
+		{month: 2, want: "febrero"},
+		{month: 3, want: "3"},
+		{month: 0, want: "0"},
+		{month: 13, want: "13"},
+	}
+	for _, tt := range tests {
+		if got := c.monthName(tt.month); got != tt.want {
+			t.Errorf("monthName(%d) = %q, want %q", tt.month, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogFormatMoney(t *testing.T) {
+	us := &catalog{DecimalSeparator: ".", GroupSeparator: ","}
+	european := &catalog{DecimalSeparator: ",", GroupSeparator: ".", MoneyPattern: "{sign}{amount} {symbol}"}
+
+	tests := []struct {
+		name    string
+		catalog *catalog
+		amount  entities.Money
+		want    string
+	}{
+		{name: "small amount", catalog: us, amount: entities.NewMoney(6050, "USD"), want: "$60.50 USD"},
+		{name: "grouped amount", catalog: us, amount: entities.NewMoney(123456789, "MXN"), want: "$1,234,567.89 MXN"},
+		{name: "negative amount", catalog: us, amount: entities.NewMoney(-100000, "USD"), want: "-$1,000.00 USD"},
+		{name: "unknown symbol", catalog: us, amount: entities.NewMoney(100, "JPY"), want: "1.00 JPY"},
+		{name: "custom pattern and separators", catalog: european, amount: entities.NewMoney(-123456, "EUR"), want: "-1.234,56 €"},
+	}
+	for _, tt := range tests {
+		if got := tt.catalog.formatMoney(tt.amount); got != tt.want {
+			t.Errorf("%s: formatMoney() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCatalogsOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "locales"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"es-MX.json": `{"messages": {"title": "Estado de cuenta"}}`,
+		"pt-BR.json": `{"decimal_separator": ",", "group_separator": ".", "messages": {"title": "Resumo"}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "locales", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catalogs, err := loadCatalogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback := catalogs["en-us"]
+
+	tests := []struct {
+		locale string
+		key    string
+		want   string
+	}{
+		{locale: "es-MX", key: "title", want: "Estado de cuenta"},
+		{locale: "es-MX", key: "balance", want: "Balance"}, // The override replaces the whole catalog
+		{locale: "pt-BR", key: "title", want: "Resumo"},
+		{locale: "en-US", key: "title", want: "Transactions Summary"},
+	}
+	for _, tt := range tests {
+		if got := catalogFor(catalogs, tt.locale).translate(fallback, tt.key); got != tt.want {
+			t.Errorf("%s %q = %q, want %q", tt.locale, tt.key, got, tt.want)
+		}
+	}
+
+	// A catalog that is not valid JSON is an error
+	if err := os.WriteFile(filepath.Join(dir, "locales", "fr-FR.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCatalogs(dir); err == nil {
+		t.Error("loadCatalogs() with an invalid catalog returned no error")
+	}
+}
@@ -0,0 +1,231 @@
+package emailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// defaultTemplates holds the templates, message catalogs and brand used when a
+// deployment does not override them.
+//
+//go:embed templates/*.tmpl locales/*.json brand.json
+var defaultTemplates embed.FS
+
+// Template file names. A template directory may provide any subset of them;
+// the missing ones fall back to the embedded defaults.
+const (
+	subjectTemplate = "subject.txt.tmpl"
+	textTemplate    = "summary.txt.tmpl"
+	htmlTemplate    = "summary.html.tmpl"
+	brandFile       = "brand.json"
+)
+
+// Brand holds the values of the layout that change between deployments,
+// read from brand.json. Links are only rendered when their URL is set.
+type Brand struct {
+	Name           string `json:"name"`
+	LogoURL        string `json:"logo_url"`
+	PrimaryColor   string `json:"primary_color"`
+	PrivacyURL     string `json:"privacy_url"`
+	UnsubscribeURL string `json:"unsubscribe_url"`
+}
+
+// TemplateRenderer implements the SummaryRenderer interface with html/template
+// for the HTML body and text/template for the subject and plain-text body.
+// Emails are localized with the catalog matching the account locale.
+type TemplateRenderer struct {
+	Brand    Brand
+	subject  *texttemplate.Template
+	text     *texttemplate.Template
+	html     *htmltemplate.Template
+	catalogs map[string]*catalog
+	now      func() time.Time // Clock for the copyright year
+}
+
+// Ensure TemplateRenderer implements interfaces.SummaryRenderer and interfaces.Localizer
+var (
+	_ interfaces.SummaryRenderer = &TemplateRenderer{}
+	_ interfaces.Localizer       = &TemplateRenderer{}
+)
+
+// templateData is the data available to every template.
+type templateData struct {
+	Brand   Brand
+	Summary *entities.SummaryResult
+	Year    int // Current year, for the copyright notice
+}
+
+// NewTemplateRenderer creates a new TemplateRenderer. Templates, catalogs
+// (dir/locales/<locale>.json) and the brand (dir/brand.json) found in dir
+// override the embedded defaults; an empty dir uses only the defaults.
+func NewTemplateRenderer(dir string) (*TemplateRenderer, error) {
+	catalogs, err := loadCatalogs(dir)
+	if err != nil {
+		return nil, err
+	}
+	brand, err := loadBrand(dir)
+	if err != nil {
+		return nil, err
+	}
+	funcs := localeFuncs(catalogs, DefaultLocale)
+
+	subjectSource, err := readTemplate(dir, subjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := texttemplate.New(subjectTemplate).Funcs(funcs).Parse(subjectSource)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", subjectTemplate, err)
+	}
+
+	textSource, err := readTemplate(dir, textTemplate)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(textTemplate).Funcs(funcs).Parse(textSource)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", textTemplate, err)
+	}
+
+	htmlSource, err := readTemplate(dir, htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New(htmlTemplate).Funcs(funcs).Parse(htmlSource)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", htmlTemplate, err)
+	}
+
+	return &TemplateRenderer{
+		Brand:    brand,
+		subject:  subject,
+		text:     text,
+		html:     html,
+		catalogs: catalogs,
+		now:      time.Now,
+	}, nil
+}
+
+// readTemplate returns the template from dir when present, otherwise the embedded default.
+func readTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("could not read template %s: %w", name, err)
+		}
+	}
+
+	data, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", fmt.Errorf("could not read default template %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// loadBrand returns the embedded brand with the values set in dir/brand.json
+// applied over it, so a deployment may change only its name or colour.
+func loadBrand(dir string) (Brand, error) {
+	var brand Brand
+	data, err := defaultTemplates.ReadFile(brandFile)
+	if err != nil {
+		return Brand{}, fmt.Errorf("could not read default brand: %w", err)
+	}
+	if err := json.Unmarshal(data, &brand); err != nil {
+		return Brand{}, fmt.Errorf("could not parse default brand: %w", err)
+	}
+
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, brandFile))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Brand{}, fmt.Errorf("could not read %s: %w", brandFile, err)
+		}
+		if err == nil {
+			// Keys missing from the file keep their default values
+			if err := json.Unmarshal(data, &brand); err != nil {
+				return Brand{}, fmt.Errorf("could not parse %s: %w", brandFile, err)
+			}
+		}
+	}
+	return brand, nil
+}
+
+// localeFuncs returns the template functions bound to the catalog of a locale:
+// "t" translates a message key, "month" names a month number and "money"
+// formats an amount.
+func localeFuncs(catalogs map[string]*catalog, locale string) map[string]any {
+	fallback := catalogs[strings.ToLower(DefaultLocale)]
+	c := catalogFor(catalogs, locale)
+	return map[string]any{
+		"t":     func(key string) string { return c.translate(fallback, key) },
+		"month": c.monthName,
+		"money": c.formatMoney,
+	}
+}
+
+// Translate returns the message for key in the catalog of locale.
+func (r *TemplateRenderer) Translate(locale, key string) string {
+	return catalogFor(r.catalogs, locale).translate(r.catalogs[strings.ToLower(DefaultLocale)], key)
+}
+
+// MonthName returns the name of a month number (1 to 12) in locale.
+func (r *TemplateRenderer) MonthName(locale string, month int) string {
+	return catalogFor(r.catalogs, locale).monthName(month)
+}
+
+// FormatMoney formats an amount with the separators and money pattern of locale.
+func (r *TemplateRenderer) FormatMoney(locale string, m entities.Money) string {
+	return catalogFor(r.catalogs, locale).formatMoney(m)
+}
+
+// RenderSummary renders the subject, HTML body and plain-text body of a summary
+// email in the locale of the summary.
+func (r *TemplateRenderer) RenderSummary(summary *entities.SummaryResult) (*entities.EmailMessage, error) {
+	data := templateData{
+		Brand:   r.Brand,
+		Summary: summary,
+		Year:    r.now().Year(),
+	}
+
+	// Bind the template functions to the summary locale on copies of the templates
+	funcs := localeFuncs(r.catalogs, summary.Locale)
+	subjectTmpl := texttemplate.Must(r.subject.Clone()).Funcs(funcs)
+	textTmpl := texttemplate.Must(r.text.Clone()).Funcs(funcs)
+	htmlTmpl, err := r.html.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("could not prepare HTML template: %w", err)
+	}
+	htmlTmpl.Funcs(funcs)
+
+	var subject, text, html bytes.Buffer
+	if err := subjectTmpl.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("could not render subject: %w", err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("could not render text body: %w", err)
+	}
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("could not render HTML body: %w", err)
+	}
+
+	return &entities.EmailMessage{
+		Subject:  strings.TrimSpace(subject.String()),
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
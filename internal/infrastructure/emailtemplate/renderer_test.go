@@ -0,0 +1,159 @@
+package emailtemplate
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testSummary returns a summary in locale with two currencies and two months.
+func testSummary(locale string) *entities.SummaryResult {
+	money := func(cents int64, currency string) entities.Money { return entities.NewMoney(cents, currency) }
+	return &entities.SummaryResult{Locale: locale, Currencies: []entities.CurrencySummary{
+		{
+			Currency:    "USD",
+			TotalCredit: money(8100, "USD"),
+			TotalDebit:  money(-1025, "USD"),
+			MonthlySummaries: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 3, AverageCredit: money(4050, "USD"), AverageDebit: money(-1025, "USD"), TotalCredits: money(8100, "USD"), TotalDebits: money(-1025, "USD"), NetAmount: money(7075, "USD"), ClosingBalance: money(7075, "USD")},
+				{Month: "August", MonthNumber: 8, Year: 2024, NumTransactions: 0, AverageCredit: money(0, "USD"), AverageDebit: money(0, "USD"), TotalCredits: money(0, "USD"), TotalDebits: money(0, "USD"), NetAmount: money(0, "USD"), ClosingBalance: money(7075, "USD")},
+			},
+		},
+		{
+			Currency:    "MXN",
+			TotalCredit: money(123456, "MXN"),
+			TotalDebit:  money(0, "MXN"),
+			MonthlySummaries: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 1, AverageCredit: money(123456, "MXN"), AverageDebit: money(0, "MXN"), TotalCredits: money(123456, "MXN"), TotalDebits: money(0, "MXN"), NetAmount: money(123456, "MXN"), ClosingBalance: money(123456, "MXN")},
+			},
+		},
+	}}
+}
+
+// newTestRenderer returns a renderer of dir with the clock fixed in 2024.
+func newTestRenderer(t *testing.T, dir string) *TemplateRenderer {
+	t.Helper()
+	renderer, err := NewTemplateRenderer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderer.now = func() time.Time { return time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC) }
+	return renderer
+}
+
+// checkGolden compares got with testdata/name, or rewrites it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file (run go test -update to rewrite it):\n%s", name, got)
+	}
+}
+
+func TestTemplateRendererGolden(t *testing.T) {
+	tests := []struct {
+		locale      string
+		wantSubject string
+	}{
+		{locale: "en-US", wantSubject: "Monthly Transactions Summary"},
+		{locale: "es-MX", wantSubject: "Resumen mensual de movimientos"},
+	}
+
+	renderer := newTestRenderer(t, "")
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			message, err := renderer.RenderSummary(testSummary(tt.locale))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if message.Subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", message.Subject, tt.wantSubject)
+			}
+			checkGolden(t, "summary."+tt.locale+".html.golden", message.HTMLBody)
+			checkGolden(t, "summary."+tt.locale+".txt.golden", message.TextBody)
+		})
+	}
+}
+
+func TestNewTemplateRendererOverrides(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		subjectTemplate: "Statement from {{.Brand.Name}}\n",
+		brandFile:       `{"name": "Acme <Bank>", "privacy_url": "https://example.com/privacy"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renderer := newTestRenderer(t, dir)
+	message, err := renderer.RenderSummary(testSummary("en-US"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "subject from the directory", got: message.Subject, want: "Statement from Acme <Bank>"},
+		{name: "brand name from the directory", got: renderer.Brand.Name, want: "Acme <Bank>"},
+		{name: "default logo kept", got: renderer.Brand.LogoURL, want: "https://upload.wikimedia.org/wikipedia/commons/thumb/b/b0/Stori_Logo_2023.svg/512px-Stori_Logo_2023.svg.png"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// The HTML body is escaped and shows only the links that are set
+	for _, want := range []string{"Acme &lt;Bank&gt;", `href="https://example.com/privacy"`} {
+		if !strings.Contains(message.HTMLBody, want) {
+			t.Errorf("HTML body does not contain %q", want)
+		}
+	}
+	if strings.Contains(message.HTMLBody, "Unsubscribe") {
+		t.Error("HTML body shows an unsubscribe link without its URL")
+	}
+}
+
+func TestNewTemplateRendererInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "template", file: htmlTemplate, content: "{{.Summary"},
+		{name: "brand", file: brandFile, content: "{"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewTemplateRenderer(dir); err == nil {
+				t.Errorf("NewTemplateRenderer() with an invalid %s returned no error", tt.file)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// column describes a transaction field and the names accepted for it in a
+// CSV header, a JSON Lines object or a Parquet schema.
+type column struct {
+	name     string
+	aliases  []string
+	required bool
+}
+
+// Known columns. Names are matched case-insensitively, ignoring spaces,
+// underscores and dashes, so "AccountId", "account_id" and "Account ID" are equivalent.
+var (
+	columnID          = column{name: "Id", aliases: []string{"id", "transactionid"}}
+	columnDate        = column{name: "Date", aliases: []string{"date", "transactiondate"}, required: true}
+	columnAmount      = column{name: "Amount", aliases: []string{"amount", "transaction"}, required: true}
+	columnAccount     = column{name: "AccountId", aliases: []string{"accountid", "account"}, required: true}
+	columnDescription = column{name: "Description", aliases: []string{"description", "memo"}}
+	columnMerchant    = column{name: "Merchant", aliases: []string{"merchant", "payee"}}
+	columnCurrency    = column{name: "Currency", aliases: []string{"currency", "currencycode", "ccy"}}
+)
+
+var columns = []column{columnID, columnDate, columnAmount, columnAccount, columnDescription, columnMerchant, columnCurrency}
+
+// columnNames lists the known columns in order. It is the header of the
+// quarantined rows of formats without a header of their own, such as JSON
+// Lines and OFX, so they can be fixed and uploaded again as a CSV file.
+var columnNames = func() []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return names
+}()
+
+// header maps each known column to its index in the records.
+// Columns that are not known are ignored.
+type header map[string]int
+
+// parseHeader maps the column names of a file to column indexes and fails
+// when a required column is missing or a column appears more than once. kind
+// names the file format in errors, e.g. "CSV".
+func parseHeader(kind string, names []string) (header, error) {
+	indexes := make(header)
+
+	for i, name := range names {
+		known, ok := lookupColumn(name)
+		if !ok {
+			continue
+		}
+		if _, exists := indexes[known.name]; exists {
+			return nil, fmt.Errorf("duplicate %s column in %s header: %q", known.name, kind, name)
+		}
+		indexes[known.name] = i
+	}
+
+	for _, column := range columns {
+		if _, exists := indexes[column.name]; column.required && !exists {
+			return nil, fmt.Errorf("missing required %s column in %s header (accepted names: %s)", column.name, kind, strings.Join(column.aliases, ", "))
+		}
+	}
+
+	return indexes, nil
+}
+
+// lookupColumn returns the known column accepting name.
+func lookupColumn(name string) (column, bool) {
+	normalized := normalizeHeader(name)
+	for _, column := range columns {
+		if containsString(column.aliases, normalized) {
+			return column, true
+		}
+	}
+	return column{}, false
+}
+
+// value returns the trimmed value of a column in record, or "" when the
+// column is not present in the file.
+func (h header) value(record []string, column column) string {
+	i, exists := h[column.name]
+	if !exists || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// normalizeHeader lowercases a header name and strips spaces, underscores and dashes.
+func normalizeHeader(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff") // Excel adds a byte order mark
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '_', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,88 +1,185 @@
 package file
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"log"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 
 	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
 )
 
+// transactionNamespace is the UUID namespace used to derive deterministic transaction IDs.
+var transactionNamespace = uuid.MustParse("445126aa-18d5-4e73-bf87-1eb31180d4f9")
+
 // CSVReader implements the FileReader interface to read transactions from a CSV file.
-type CSVReader struct{}
+type CSVReader struct {
+	// StatementYear is the year applied to short M/DD dates. When zero the
+	// current year is used.
+	StatementYear int
+	// DefaultCurrency is used for rows without a currency column or value.
+	// When empty entities.DefaultCurrency is used.
+	DefaultCurrency string
+}
+
+// Ensure CSVReader implements interfaces.FileReader
+var _ interfaces.FileReader = &CSVReader{}
 
 // NewCSVReader creates a new CSVReader instance.
 func NewCSVReader() *CSVReader {
 	return &CSVReader{}
 }
 
-// ReadTransactions reads a CSV file and returns a list of transactions.
-func (r *CSVReader) ReadTransactions(reader *csv.Reader) ([]entities.Transaction, error) {
+// ReadTransactions returns the transactions of a CSV file, read one row at a
+// time while the sequence is iterated. The file source (e.g. the S3 object) is
+// used to derive deterministic transaction IDs, so re-reading the same file
+// yields the same IDs. Rows that fail validation are left out of the sequence
+// and described in the returned report; an error is only yielded when the
+// file cannot be read any further.
+func (r *CSVReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	source := file.Source
+	report := &entities.ValidationReport{Source: source}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		// Rows with a wrong number of fields are reported instead of aborting the read
+		reader := csv.NewReader(input)
+		reader.FieldsPerRecord = -1
+
+		headerRecord, err := reader.Read()
+		if err == io.EOF {
+			yield(entities.Transaction{}, fmt.Errorf("CSV file is empty"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error reading CSV: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read CSV: %w", err))
+			return
+		}
+
+		// Map the columns by header name, e.g. Id,Date,Transaction,AccountId
+		header, err := parseHeader("CSV", headerRecord)
+		if err != nil {
+			yield(entities.Transaction{}, err)
+			return
+		}
+		report.Header = headerRecord
+
+		parser := recordParser{statementYear: r.StatementYear, defaultCurrency: r.DefaultCurrency}
+		valid := 0
+		for row := 1; ; row++ {
+			// Stop reading a large file once the caller gives up
+			if err := ctx.Err(); err != nil {
+				yield(entities.Transaction{}, fmt.Errorf("could not read CSV: %w", err))
+				return
+			}
+
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					report.Add(entities.RowError{
+						Line:   parseErr.Line,
+						Reason: parseErr.Err.Error(),
+						Record: record,
+					})
+					continue
+				}
+				log.Printf("Error reading CSV: %v", err)
+				yield(entities.Transaction{}, fmt.Errorf("could not read CSV: %w", err))
+				return
+			}
+
+			line, _ := reader.FieldPos(0)
+			transaction, rowErrors := parser.parseRecord(source, row, header, record)
+			if len(rowErrors) > 0 {
+				for i := range rowErrors {
+					rowErrors[i].Line = line
+					rowErrors[i].Record = record
+				}
+				report.Add(rowErrors...)
+				continue
+			}
+			transaction.Line = line
+			transaction.Record = record
+			if !yield(transaction, nil) {
+				return
+			}
+			valid++
+		}
 
-	records, err := reader.ReadAll()
-	if err != nil {
-		log.Printf("Error reading CSV: %v", err)
-		return nil, fmt.Errorf("could not read CSV: %v", err)
+		log.Printf("CSV file contains %d valid and %d invalid records", valid, report.InvalidRows())
 	}
-	log.Printf("CSV file contains %d records", len(records)-1) // Minus header row
-
-	var transactions []entities.Transaction
 
-	// Skip the first row (header)
-	for i, record := range records {
-		if i == 0 {
-			continue // Skip header
-		}
+	return transactions, report
+}
 
-		// Example CSV structure: Id,Date,Transaction,AccountId
+// CSVFormat returns the CSV format read by reader.
+func CSVFormat(reader *CSVReader) Format {
+	return Format{
+		Name:         "csv",
+		Extensions:   []string{".csv"},
+		ContentTypes: []string{"text/csv", "application/csv"},
+		Sniff:        sniffCSV,
+		Reader:       reader,
+	}
+}
 
-		// Parse AccountId (last column)
-		accountId := strings.TrimSpace(record[2])
+// sniffCSV reports whether head looks like the start of a CSV file: text
+// whose first line has at least one comma and is not a JSON object.
+func sniffCSV(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 || sniffJSONL(head) {
+		return false
+	}
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	return bytes.IndexByte(line, ',') >= 0
+}
 
-		// Parse the transaction amount
-		amount, err := strconv.ParseFloat(record[1], 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid transaction amount in CSV: %v", err)
+// parseCurrency validates a three-letter currency code, falling back to
+// defaultCurrency, or entities.DefaultCurrency, when the value is empty.
+func parseCurrency(value string, defaultCurrency string) (string, error) {
+	if value == "" {
+		if defaultCurrency != "" {
+			return defaultCurrency, nil
 		}
+		return entities.DefaultCurrency, nil
+	}
 
-		// Parse the date (assuming the current year)
-		monthDay := strings.Split(record[0], "/")
-		if len(monthDay) != 2 {
-			return nil, fmt.Errorf("invalid date format in CSV: %s", record[0])
-		}
-		month, _ := strconv.Atoi(monthDay[0])
-		day, _ := strconv.Atoi(monthDay[1])
-
-		// Construct the date with the current year
-		year := time.Now().Year()
-		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-
-		// Determine transaction type using the function
-		transactionType := determineTransactionType(amount)
-
-		newUUID := uuid.New()
-		// Create a transaction object
-		transaction := entities.Transaction{
-			ID:              newUUID.String(),
-			AccountID:       accountId,
-			Amount:          amount,
-			TransactionDate: date,
-			Type:            transactionType,
-		}
-		transactions = append(transactions, transaction)
+	currency := strings.ToUpper(value)
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("invalid currency code, expected a 3-letter ISO 4217 code")
 	}
+	return currency, nil
+}
+
+// transactionID derives a deterministic UUID from the source file, the row
+// number and the raw row values, so re-processing a file produces the same IDs.
+func transactionID(source string, row int, date, amount, accountId string) string {
+	name := strings.Join([]string{source, strconv.Itoa(row), date, amount, accountId}, "|")
+	return uuid.NewSHA1(transactionNamespace, []byte(name)).String()
+}
 
-	return transactions, nil
+// providedTransactionID derives a deterministic UUID from the account and
+// the Id given by a file, so the same Id in another account is a different
+// transaction while re-uploads of the account keep their IDs.
+func providedTransactionID(accountId, id string) string {
+	return uuid.NewSHA1(transactionNamespace, []byte("id|"+accountId+"|"+id)).String()
 }
 
 // determineTransactionType returns "debit" if the amount is negative, otherwise "credit".
-func determineTransactionType(amount float64) string {
-	if amount < 0 {
+func determineTransactionType(amount entities.Money) string {
+	if amount.IsNegative() {
 		return "debit"
 	}
 	return "credit"
@@ -0,0 +1,306 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// read collects the transactions of an input until the first read error.
+func read(reader interfaces.FileReader, source, input string) ([]entities.Transaction, *entities.ValidationReport, error) {
+	sequence, report := reader.ReadTransactions(context.Background(), entities.FileInfo{Source: source}, strings.NewReader(input))
+	var transactions []entities.Transaction
+	for transaction, err := range sequence {
+		if err != nil {
+			return transactions, report, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, report, nil
+}
+
+// readAll reads every transaction of a CSV input that has no invalid rows.
+func readAll(t *testing.T, source, input string) []entities.Transaction {
+	t.Helper()
+	transactions, report, err := read(NewCSVReader(), source, input)
+	if err != nil {
+		t.Fatalf("ReadTransactions() error: %v", err)
+	}
+	if report.HasErrors() {
+		t.Fatalf("ReadTransactions() reported invalid rows: %v", report.RowErrors)
+	}
+	return transactions
+}
+
+func TestCSVReaderTransactionIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  string
+		second string
+		sameID bool
+	}{
+		{
+			name:   "derived IDs are stable across reads",
+			first:  "Date,Transaction,AccountId\n7/15,10,1\n",
+			second: "Date,Transaction,AccountId\n7/15,10,1\n",
+			sameID: true,
+		},
+		{
+			name:   "derived IDs depend on the row values",
+			first:  "Date,Transaction,AccountId\n7/15,10,1\n",
+			second: "Date,Transaction,AccountId\n7/15,11,1\n",
+			sameID: false,
+		},
+		{
+			name:   "provided IDs are namespaced by account",
+			first:  "Id,Date,Transaction,AccountId\nA1,7/15,10,1\n",
+			second: "Id,Date,Transaction,AccountId\nA1,7/15,10,2\n",
+			sameID: false,
+		},
+		{
+			name:   "provided IDs do not depend on the row",
+			first:  "Id,Date,Transaction,AccountId\nA1,7/15,10,1\n",
+			second: "Id,Date,Transaction,AccountId\nB2,7/16,5,1\nA1,7/15,10,1\n",
+			sameID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := readAll(t, "s3://bucket/file.csv", tt.first)
+			second := readAll(t, "s3://bucket/file.csv", tt.second)
+			last := second[len(second)-1]
+			if got := first[0].ID == last.ID; got != tt.sameID {
+				t.Errorf("IDs %s and %s: same = %v, want %v", first[0].ID, last.ID, got, tt.sameID)
+			}
+		})
+	}
+}
+
+func TestCSVReaderDerivedIDsDependOnSource(t *testing.T) {
+	input := "Date,Transaction,AccountId\n7/15,10,1\n"
+	first := readAll(t, "s3://bucket/a.csv", input)
+	second := readAll(t, "s3://bucket/b.csv", input)
+	if first[0].ID == second[0].ID {
+		t.Errorf("files a.csv and b.csv derived the same ID %s", first[0].ID)
+	}
+}
+
+func TestCSVReaderDates(t *testing.T) {
+	tests := []struct {
+		name          string
+		date          string
+		statementYear int
+		want          string
+		wantErr       string // Reason of the expected row error
+	}{
+		{name: "ISO date", date: "2023-12-31", want: "2023-12-31"},
+		{name: "US date", date: "2/29/2024", want: "2024-02-29"},
+		{name: "short date in the statement year", date: "7/15", statementYear: 2022, want: "2022-07-15"},
+		{name: "short date in the current year", date: "7/15", want: fmt.Sprintf("%d-07-15", time.Now().Year())},
+		{name: "impossible day", date: "2/30/2024", wantErr: "invalid date"},
+		{name: "unknown format", date: "July 15", wantErr: "invalid date format"},
+		{name: "missing date", date: "", wantErr: "missing date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewCSVReader()
+			reader.StatementYear = tt.statementYear
+			input := "Date,Transaction,AccountId\n" + tt.date + ",10,1\n"
+			transactions, report, err := read(reader, "test", input)
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+			if tt.wantErr != "" {
+				if len(report.RowErrors) != 1 || report.RowErrors[0].Column != "Date" || !strings.Contains(report.RowErrors[0].Reason, tt.wantErr) {
+					t.Fatalf("row errors = %v, want one Date error: %s", report.RowErrors, tt.wantErr)
+				}
+				return
+			}
+			if got := transactions[0].TransactionDate.Format("2006-01-02"); got != tt.want {
+				t.Errorf("date = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVReaderHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		wantAccount     string
+		wantAmount      int64 // Minor units
+		wantDescription string
+		wantMerchant    string
+		wantErr         bool
+	}{
+		{
+			name:        "baseline header",
+			input:       "Date,Transaction,AccountId\n7/15,10,1\n",
+			wantAccount: "1",
+			wantAmount:  1000,
+		},
+		{
+			name:            "columns in any order with aliases and unknown columns",
+			input:           "Payee,Account ID,Notes,amount,Memo,transaction_date\nShop,2,x,-5.5,Groceries,2024-07-15\n",
+			wantAccount:     "2",
+			wantAmount:      -550,
+			wantDescription: "Groceries",
+			wantMerchant:    "Shop",
+		},
+		{
+			name:        "byte order mark before the first header",
+			input:       "\ufeffDate,Amount,Account\n7/15,10,1\n",
+			wantAccount: "1",
+			wantAmount:  1000,
+		},
+		{
+			name:    "missing required column",
+			input:   "Date,Transaction\n7/15,10\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate column",
+			input:   "Date,Amount,Transaction,AccountId\n7/15,10,10,1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, _, err := read(NewCSVReader(), "test", tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadTransactions() read %v, want an error", transactions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+			got := transactions[0]
+			if got.AccountID != tt.wantAccount || got.Amount.Amount != tt.wantAmount || got.Description != tt.wantDescription || got.Merchant != tt.wantMerchant {
+				t.Errorf("transaction = %s %v %q %q, want %s %v %q %q", got.AccountID, got.Amount.Amount, got.Description, got.Merchant,
+					tt.wantAccount, tt.wantAmount, tt.wantDescription, tt.wantMerchant)
+			}
+		})
+	}
+}
+
+func TestCSVReaderRowErrors(t *testing.T) {
+	input := "Date,Transaction,AccountId\n" +
+		"7/15,10,1\n" +
+		"7/32,abc,1\n" + // Two invalid values in one row
+		"7/16,5,\n" +
+		"7/17,5\n" + // Missing the last field
+		"7/18,-5,2\n"
+
+	transactions, report, err := read(NewCSVReader(), "test", input)
+	if err != nil {
+		t.Fatalf("ReadTransactions() error: %v", err)
+	}
+	if len(transactions) != 2 {
+		t.Errorf("got %d valid transactions, want 2: %v", len(transactions), transactions)
+	}
+
+	want := []struct {
+		line   int
+		column string
+	}{
+		{3, "Amount"},
+		{3, "Date"},
+		{4, "AccountId"},
+		{5, "AccountId"},
+	}
+	if len(report.RowErrors) != len(want) {
+		t.Fatalf("got %d row errors, want %d: %v", len(report.RowErrors), len(want), report.RowErrors)
+	}
+	for i, w := range want {
+		got := report.RowErrors[i]
+		if got.Line != w.line || got.Column != w.column {
+			t.Errorf("row error %d = line %d, column %q, want line %d, column %q", i, got.Line, got.Column, w.line, w.column)
+		}
+		if len(got.Record) == 0 {
+			t.Errorf("row error %d does not keep the raw row", i)
+		}
+	}
+	if got := report.InvalidRows(); got != 3 {
+		t.Errorf("InvalidRows() = %d, want 3", got)
+	}
+}
+
+func TestCSVReaderTextLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		merchant    string
+		wantColumns []string
+	}{
+		{name: "fits", description: strings.Repeat("a", 255), merchant: strings.Repeat("é", 255)},
+		{name: "description too long", description: strings.Repeat("a", 256), wantColumns: []string{"Description"}},
+		{name: "both too long", description: strings.Repeat("a", 256), merchant: strings.Repeat("é", 256), wantColumns: []string{"Description", "Merchant"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "Date,Amount,AccountId,Description,Merchant\n7/15,10,1," + tt.description + "," + tt.merchant + "\n"
+			transactions, report, err := read(NewCSVReader(), "test", input)
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+			if len(report.RowErrors) != len(tt.wantColumns) {
+				t.Fatalf("got %d row errors, want %d: %v", len(report.RowErrors), len(tt.wantColumns), report.RowErrors)
+			}
+			for i, column := range tt.wantColumns {
+				if got := report.RowErrors[i]; got.Column != column || got.Line != 2 {
+					t.Errorf("row error %d = line %d, column %q, want line 2, column %q", i, got.Line, got.Column, column)
+				}
+			}
+			if len(tt.wantColumns) == 0 && len(transactions) != 1 {
+				t.Errorf("got %d transactions, want 1", len(transactions))
+			}
+		})
+	}
+}
+
+func TestCSVReaderCurrencies(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		defaultCurrency string
+		want            string
+		wantErr         bool
+	}{
+		{name: "no currency column", input: "Date,Amount,AccountId\n7/15,10,1\n", want: "USD"},
+		{name: "configured default", input: "Date,Amount,AccountId\n7/15,10,1\n", defaultCurrency: "MXN", want: "MXN"},
+		{name: "empty value", input: "Date,Amount,AccountId,Currency\n7/15,10,1,\n", defaultCurrency: "EUR", want: "EUR"},
+		{name: "code is uppercased", input: "Date,Amount,AccountId,Ccy\n7/15,10,1,mxn\n", want: "MXN"},
+		{name: "invalid code", input: "Date,Amount,AccountId,Currency\n7/15,10,1,PESOS\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewCSVReader()
+			reader.DefaultCurrency = tt.defaultCurrency
+			transactions, report, err := read(reader, "test", tt.input)
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+			if tt.wantErr {
+				if len(report.RowErrors) != 1 || report.RowErrors[0].Column != "Currency" {
+					t.Fatalf("row errors = %v, want one Currency error", report.RowErrors)
+				}
+				return
+			}
+			if got := transactions[0].Amount.Currency; got != tt.want {
+				t.Errorf("currency = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
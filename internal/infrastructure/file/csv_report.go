@@ -0,0 +1,68 @@
+package file
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"transactions-summary/internal/entities"
+)
+
+// WriteErrorReport writes one CSV row per invalid value: line, column, value
+// and reason. Reports of archives start with the file of each row. The
+// invalid rows the report left out are counted in a last row.
+func WriteErrorReport(w io.Writer, report *entities.ValidationReport) error {
+	withFile := slices.ContainsFunc(report.RowErrors, func(rowError entities.RowError) bool {
+		return rowError.File != ""
+	})
+
+	writer := csv.NewWriter(w)
+	header := []string{"Line", "Column", "Value", "Reason"}
+	if withFile {
+		header = append([]string{"File"}, header...)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("could not write error report: %w", err)
+	}
+	for _, rowError := range report.RowErrors {
+		record := []string{strconv.Itoa(rowError.Line), rowError.Column, rowError.Value, rowError.Reason}
+		if withFile {
+			record = append([]string{rowError.File}, record...)
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("could not write error report: %w", err)
+		}
+	}
+	if report.OmittedRows > 0 {
+		record := make([]string, len(header))
+		record[len(record)-1] = fmt.Sprintf("%d more invalid rows not listed", report.OmittedRows)
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("could not write error report: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteQuarantine writes the header and the raw invalid rows, once per row,
+// so the file can be fixed and uploaded again.
+func WriteQuarantine(w io.Writer, report *entities.ValidationReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(report.Header); err != nil {
+		return fmt.Errorf("could not write quarantine file: %w", err)
+	}
+	written := make(map[entities.RowKey]bool)
+	for _, rowError := range report.RowErrors {
+		if written[rowError.Key()] || rowError.Record == nil {
+			continue
+		}
+		written[rowError.Key()] = true
+		if err := writer.Write(rowError.Record); err != nil {
+			return fmt.Errorf("could not write quarantine file: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
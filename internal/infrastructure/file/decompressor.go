@@ -0,0 +1,375 @@
+package file
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// compression is a compression format a Decompressor can read.
+type compression struct {
+	name         string
+	extensions   []string
+	contentTypes []string
+	magic        [][]byte
+}
+
+var (
+	compressionGzip = compression{
+		name:         "gzip",
+		extensions:   []string{".gz", ".gzip"},
+		contentTypes: []string{"application/gzip", "application/x-gzip"},
+		magic:        [][]byte{{0x1f, 0x8b}},
+	}
+	compressionZip = compression{
+		name:         "zip",
+		extensions:   []string{".zip"},
+		contentTypes: []string{"application/zip", "application/x-zip-compressed"},
+		magic:        [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")},
+	}
+)
+
+var compressions = []compression{compressionGzip, compressionZip}
+
+// Defaults of Decompressor.
+const (
+	defaultMaxDecompressedSize = 1 << 30 // 1 GiB
+	defaultMaxZipFiles         = 1000
+)
+
+// Decompressor implements the FileReader interface by decompressing gzip
+// files and zip archives before handing them to Reader. Other files are handed
+// to Reader as they are.
+//
+// The compression is detected from the file extension, then the content type,
+// then the first bytes of the file. Every file of a zip archive is read in
+// turn, so its transactions and invalid rows make up one upload. Files of an
+// archive whose format is unknown are logged and skipped.
+type Decompressor struct {
+	Reader interfaces.FileReader
+	// MaxSize is the largest decompressed size of an upload, all the files of
+	// an archive together, and of a zip archive copied to a temporary file.
+	// Zero means no limit.
+	MaxSize int64
+	// MaxFiles is the largest number of files read from a zip archive.
+	MaxFiles int
+}
+
+// Ensure Decompressor implements interfaces.FileReader
+var _ interfaces.FileReader = &Decompressor{}
+
+// NewDecompressor creates a new Decompressor reading the decompressed files
+// with reader, with the default size and file limits.
+func NewDecompressor(reader interfaces.FileReader) *Decompressor {
+	return &Decompressor{
+		Reader:   reader,
+		MaxSize:  defaultMaxDecompressedSize,
+		MaxFiles: defaultMaxZipFiles,
+	}
+}
+
+// ReadTransactions decompresses the file while the sequence is iterated and
+// returns the transactions read from it. The report lists the invalid rows of
+// every file in an archive, each with the name of its file.
+//
+// A gzip file is read as the file it compresses: "transactions.csv.gz" is
+// read as "transactions.csv". A file in a zip archive is read as
+// "<archive>/<name>", so each one derives distinct transaction IDs.
+func (d *Decompressor) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	found, input, err := detectCompression(file, input)
+	if err != nil {
+		return func(yield func(entities.Transaction, error) bool) {
+			yield(entities.Transaction{}, err)
+		}, &entities.ValidationReport{Source: file.Source}
+	}
+
+	switch found.name {
+	case compressionGzip.name:
+		log.Printf("Decompressing %s as gzip", file.Source)
+		return d.readGzip(ctx, file, input)
+	case compressionZip.name:
+		log.Printf("Decompressing %s as zip", file.Source)
+		return d.readZip(ctx, file, input)
+	default:
+		return d.Reader.ReadTransactions(ctx, file, input)
+	}
+}
+
+// readGzip reads the file compressed in a gzip file.
+func (d *Decompressor) readGzip(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	report := &entities.ValidationReport{Source: file.Source}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		decompressed, err := gzip.NewReader(input)
+		if err != nil {
+			log.Printf("Error reading gzip: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read gzip: %w", err))
+			return
+		}
+		defer decompressed.Close()
+
+		// Detect the format from the name without the compression extension
+		inner := file
+		inner.ContentType = ""
+		if extension := path.Ext(file.Source); slices.Contains(compressionGzip.extensions, strings.ToLower(extension)) {
+			inner.Source = strings.TrimSuffix(file.Source, extension)
+		}
+
+		limit := &sizeLimit{max: d.MaxSize}
+		d.readFile(ctx, yield, report, "", inner, limit.reader(decompressed))
+	}
+
+	return transactions, report
+}
+
+// readZip reads every file of a zip archive. Archives are read from their
+// end, so an input that cannot be read at random, such as an S3 object, is
+// first copied to a temporary file.
+func (d *Decompressor) readZip(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	report := &entities.ValidationReport{Source: file.Source}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		readerAt, size, cleanup, err := randomAccess(input, "tsummary-*.zip", d.MaxSize)
+		if err != nil {
+			log.Printf("Error reading zip: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read zip: %w", err))
+			return
+		}
+		defer cleanup()
+
+		archive, err := zip.NewReader(readerAt, size)
+		if err != nil {
+			log.Printf("Error reading zip: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read zip: %w", err))
+			return
+		}
+
+		// Check the sizes the archive declares before decompressing anything;
+		// the size limit below still applies when they are wrong
+		var entries []*zip.File
+		var declared uint64
+		for _, entry := range archive.File {
+			if !skipZipEntry(entry) {
+				entries = append(entries, entry)
+				declared += entry.UncompressedSize64
+			}
+		}
+		if d.MaxFiles > 0 && len(entries) > d.MaxFiles {
+			yield(entities.Transaction{}, fmt.Errorf("zip archive %s contains %d files, more than the limit of %d", file.Source, len(entries), d.MaxFiles))
+			return
+		}
+		if d.MaxSize > 0 && declared > uint64(d.MaxSize) {
+			yield(entities.Transaction{}, fmt.Errorf("zip archive %s decompresses to %d bytes, more than the limit of %d", file.Source, declared, d.MaxSize))
+			return
+		}
+
+		limit := &sizeLimit{max: d.MaxSize}
+		files := 0
+		for _, entry := range entries {
+			// Stop between files once the caller gives up
+			if err := ctx.Err(); err != nil {
+				yield(entities.Transaction{}, fmt.Errorf("could not read zip: %w", err))
+				return
+			}
+
+			body, err := entry.Open()
+			if err != nil {
+				log.Printf("Error reading %s in zip: %v", entry.Name, err)
+				yield(entities.Transaction{}, fmt.Errorf("could not read %s in zip: %w", entry.Name, err))
+				return
+			}
+			inner := entities.FileInfo{Source: file.Source + "/" + entry.Name, Format: file.Format}
+			read, done := d.readFile(ctx, yield, report, entry.Name, inner, limit.reader(body))
+			body.Close()
+			if !done {
+				return
+			}
+			if read {
+				files++
+			}
+		}
+
+		if files == 0 {
+			yield(entities.Transaction{}, fmt.Errorf("zip archive %s contains no transaction files", file.Source))
+			return
+		}
+		log.Printf("Read %d files from %s", files, file.Source)
+	}
+
+	return transactions, report
+}
+
+// readFile yields the transactions of one decompressed file and adds its
+// invalid rows to report, naming them after name when it is set. A file of an
+// archive whose format is unknown is skipped: read is false. done is false
+// when the sequence must stop: the caller gave up or the file could not be
+// read.
+func (d *Decompressor) readFile(ctx context.Context, yield func(entities.Transaction, error) bool, report *entities.ValidationReport, name string, file entities.FileInfo, input io.Reader) (read bool, done bool) {
+	transactions, fileReport := d.Reader.ReadTransactions(ctx, file, input)
+
+	// The quarantine file has a single header, taken from the first file
+	// read. Rows of files with other columns are only reported.
+	sameHeader := func() bool {
+		if report.Header == nil {
+			report.Header = fileReport.Header
+		}
+		return slices.Equal(report.Header, fileReport.Header)
+	}
+
+	for transaction, err := range transactions {
+		if err != nil {
+			if name != "" && errors.Is(err, ErrUnknownFormat) {
+				log.Printf("Skipping %s in archive: %v", name, err)
+				return false, true
+			}
+			if name != "" {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			yield(entities.Transaction{}, err)
+			return false, false
+		}
+		// Rows of unknown accounts are reported from the transaction itself
+		transaction.File = name
+		if !sameHeader() {
+			transaction.Record = nil
+		}
+		if !yield(transaction, nil) {
+			return false, false
+		}
+	}
+
+	keepRecords := sameHeader()
+	if !keepRecords && fileReport.HasErrors() {
+		log.Printf("Invalid rows of %s will not be quarantined: its columns differ from the first file", file.Source)
+	}
+
+	// Add the errors a row at a time, so the report keeps its cap
+	rowErrors := fileReport.RowErrors
+	for len(rowErrors) > 0 {
+		n := 1
+		for n < len(rowErrors) && rowErrors[n].Line == rowErrors[0].Line {
+			n++
+		}
+		row := slices.Clone(rowErrors[:n])
+		for i := range row {
+			row[i].File = name
+			if !keepRecords {
+				row[i].Record = nil
+			}
+		}
+		report.Add(row...)
+		rowErrors = rowErrors[n:]
+	}
+	report.OmittedRows += fileReport.OmittedRows
+	return true, true
+}
+
+// sizeLimit caps the bytes read through its readers, together, at max. Once
+// the cap is passed reads fail with an error, so a small compressed upload
+// cannot expand into an unbounded one. A max of zero means no limit.
+type sizeLimit struct {
+	max  int64
+	read int64
+}
+
+// reader returns input with its reads counted against the limit.
+func (l *sizeLimit) reader(input io.Reader) io.Reader {
+	if l.max <= 0 {
+		return input
+	}
+	return &limitedReader{limit: l, input: input}
+}
+
+// limitedReader reads through an io.LimitReader that allows one byte past the
+// remaining budget, which tells a file of exactly the limit from a larger one.
+type limitedReader struct {
+	limit *sizeLimit
+	input io.Reader
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := io.LimitReader(r.input, r.limit.max-r.limit.read+1).Read(p)
+	r.limit.read += int64(n)
+	if r.limit.read > r.limit.max {
+		return 0, fmt.Errorf("decompressed upload is larger than the limit of %d bytes", r.limit.max)
+	}
+	return n, err
+}
+
+// skipZipEntry reports whether a zip entry is not a transaction file:
+// directories, hidden files and the metadata macOS adds to archives.
+func skipZipEntry(entry *zip.File) bool {
+	return entry.FileInfo().IsDir() ||
+		strings.HasPrefix(path.Base(entry.Name), ".") ||
+		strings.HasPrefix(entry.Name, "__MACOSX/")
+}
+
+// detectCompression chooses the compression of a file from its extension,
+// content type or first bytes. It returns no compression for other files,
+// along with the input to read in place of the original one.
+func detectCompression(file entities.FileInfo, input io.Reader) (compression, io.Reader, error) {
+	if extension := strings.ToLower(path.Ext(file.Source)); extension != "" {
+		for _, c := range compressions {
+			if slices.Contains(c.extensions, extension) {
+				return c, input, nil
+			}
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(file.ContentType); err == nil {
+		for _, c := range compressions {
+			if slices.Contains(c.contentTypes, mediaType) {
+				return c, input, nil
+			}
+		}
+	}
+
+	head, input, err := peekHead(input, 4)
+	if err != nil {
+		return compression{}, input, fmt.Errorf("could not read file: %w", err)
+	}
+	for _, c := range compressions {
+		for _, magic := range c.magic {
+			if bytes.HasPrefix(head, magic) {
+				return c, input, nil
+			}
+		}
+	}
+	return compression{}, input, nil
+}
+
+// peekHead returns the first n bytes of input without consuming them, along
+// with the input to read in place of the original one. An input that can be
+// read at random, such as an *os.File at its start, is kept as it is so the
+// readers of formats like Parquet can still read it at random.
+func peekHead(input io.Reader, n int) ([]byte, io.Reader, error) {
+	if readerAt, ok := input.(io.ReaderAt); ok {
+		head := make([]byte, n)
+		read, err := readerAt.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			return nil, input, err
+		}
+		return head[:read], input, nil
+	}
+
+	buffered := bufio.NewReaderSize(input, sniffSize)
+	head, err := buffered.Peek(n)
+	if err != nil && err != io.EOF {
+		return nil, buffered, err
+	}
+	return head, buffered, nil
+}
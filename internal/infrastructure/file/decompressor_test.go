@@ -0,0 +1,255 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"transactions-summary/internal/entities"
+)
+
+const decompressorCSV = "Date,Transaction,AccountId\n7/15,+60.5,1\n7/28,bad,1\n"
+
+// readUpload reads every transaction of file from input.
+func readUpload(reader *Decompressor, file entities.FileInfo, input io.Reader) ([]entities.Transaction, *entities.ValidationReport, error) {
+	sequence, report := reader.ReadTransactions(context.Background(), file, input)
+	var transactions []entities.Transaction
+	for transaction, err := range sequence {
+		if err != nil {
+			return transactions, report, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, report, nil
+}
+
+// gzipFile compresses content with gzip.
+func gzipFile(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipFile archives files, given as name and content pairs, in order.
+func zipFile(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		entry, err := writer.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressorReadTransactions(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      entities.FileInfo
+		input     []byte
+		maxSize   int64
+		maxFiles  int
+		stream    bool // Hide that the input can be read at random
+		want      int
+		wantFiles []string // File of each row error
+		wantErr   string
+	}{
+		{
+			name:      "plain file",
+			file:      entities.FileInfo{Source: "transactions.csv"},
+			input:     []byte(decompressorCSV),
+			want:      1,
+			wantFiles: []string{""},
+		},
+		{
+			name:      "gzip by extension",
+			file:      entities.FileInfo{Source: "transactions.csv.gz"},
+			input:     gzipFile(t, decompressorCSV),
+			want:      1,
+			wantFiles: []string{""},
+		},
+		{
+			name:      "gzip by content",
+			file:      entities.FileInfo{Source: "upload", Format: "csv"},
+			input:     gzipFile(t, decompressorCSV),
+			want:      1,
+			wantFiles: []string{""},
+		},
+		{
+			name:      "zip with several files",
+			file:      entities.FileInfo{Source: "statements.zip"},
+			input:     zipFile(t, "july.csv", decompressorCSV, "august.csv", decompressorCSV),
+			want:      2,
+			wantFiles: []string{"july.csv", "august.csv"},
+		},
+		{
+			name:      "zip skips hidden and unknown files",
+			file:      entities.FileInfo{Source: "statements.zip"},
+			input:     zipFile(t, "__MACOSX/._july.csv", "junk", "README.txt", "just some notes", "july.csv", decompressorCSV),
+			want:      1,
+			wantFiles: []string{"july.csv"},
+		},
+		{
+			name:    "zip without transaction files",
+			file:    entities.FileInfo{Source: "notes.zip"},
+			input:   zipFile(t, "README.txt", "just some notes"),
+			wantErr: "contains no transaction files",
+		},
+		{
+			name:     "zip with too many files",
+			file:     entities.FileInfo{Source: "statements.zip"},
+			input:    zipFile(t, "a.csv", decompressorCSV, "b.csv", decompressorCSV),
+			maxFiles: 1,
+			wantErr:  "more than the limit of 1",
+		},
+		{
+			name:    "zip larger than the limit",
+			file:    entities.FileInfo{Source: "statements.zip"},
+			input:   zipFile(t, "a.csv", decompressorCSV),
+			maxSize: 10,
+			wantErr: "more than the limit of 10",
+		},
+		{
+			name:    "zip copied to a temporary file larger than the limit",
+			file:    entities.FileInfo{Source: "statements.zip"},
+			input:   zipFile(t, "a.csv", strings.Repeat("8/01,1,1\n", 1000)),
+			stream:  true,
+			maxSize: 100,
+			wantErr: "file is larger than the limit of 100 bytes",
+		},
+		{
+			name:    "gzip larger than the limit",
+			file:    entities.FileInfo{Source: "transactions.csv.gz"},
+			input:   gzipFile(t, decompressorCSV+strings.Repeat("8/01,1,1\n", 1000)),
+			maxSize: 100,
+			wantErr: "larger than the limit of 100 bytes",
+		},
+		{
+			name:    "corrupt gzip",
+			file:    entities.FileInfo{Source: "transactions.csv.gz"},
+			input:   []byte("not gzip"),
+			wantErr: "could not read gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decompressor := NewDefaultReader(2024, "")
+			if tt.maxSize > 0 {
+				decompressor.MaxSize = tt.maxSize
+			}
+			if tt.maxFiles > 0 {
+				decompressor.MaxFiles = tt.maxFiles
+			}
+
+			var input io.Reader = bytes.NewReader(tt.input)
+			if tt.stream {
+				input = io.MultiReader(input)
+			}
+			transactions, report, err := readUpload(decompressor, tt.file, input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadTransactions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+			if len(transactions) != tt.want {
+				t.Errorf("got %d transactions, want %d", len(transactions), tt.want)
+			}
+			if len(report.RowErrors) != len(tt.wantFiles) {
+				t.Fatalf("got %d row errors, want %d: %v", len(report.RowErrors), len(tt.wantFiles), report.RowErrors)
+			}
+			for i, file := range tt.wantFiles {
+				if report.RowErrors[i].File != file {
+					t.Errorf("row error %d is in file %q, want %q", i, report.RowErrors[i].File, file)
+				}
+			}
+		})
+	}
+}
+
+func TestDecompressorZipTransactionIDs(t *testing.T) {
+	// The same rows in two files of an archive are distinct transactions
+	input := zipFile(t, "july.csv", decompressorCSV, "copy.csv", decompressorCSV)
+	transactions, _, err := readUpload(NewDefaultReader(2024, ""), entities.FileInfo{Source: "statements.zip"}, bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 || transactions[0].ID == transactions[1].ID {
+		t.Errorf("got transactions %+v, want two with distinct IDs", transactions)
+	}
+}
+
+func TestDecompressorZipReport(t *testing.T) {
+	// Rows of a file with other columns keep no record to quarantine
+	input := zipFile(t,
+		"july.csv", decompressorCSV,
+		"august.csv", "Date,Amount,AccountId\n8/01,bad,1\n8/02,1,1\n",
+		"september.csv", "Date,Transaction,AccountId\n9/01,bad,1\n9/02,bad,1\n")
+	decompressor := NewDefaultReader(2024, "")
+	transactions, report, err := readUpload(decompressor, entities.FileInfo{Source: "statements.zip"}, bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHeader := []string{"Date", "Transaction", "AccountId"}
+	if !slices.Equal(report.Header, wantHeader) {
+		t.Errorf("header = %q, want %q", report.Header, wantHeader)
+	}
+	for _, transaction := range transactions {
+		if (transaction.Record == nil) != (transaction.File == "august.csv") {
+			t.Errorf("transaction of %s line %d has record %q", transaction.File, transaction.Line, transaction.Record)
+		}
+	}
+	var rows []string
+	for _, rowError := range report.RowErrors {
+		rows = append(rows, fmt.Sprintf("%s:%d:%t", rowError.File, rowError.Line, rowError.Record != nil))
+	}
+	want := []string{"july.csv:3:true", "august.csv:2:false", "september.csv:2:true", "september.csv:3:true"}
+	if !slices.Equal(rows, want) {
+		t.Errorf("row errors = %v, want %v", rows, want)
+	}
+	if report.InvalidRows() != 4 {
+		t.Errorf("InvalidRows() = %d, want 4", report.InvalidRows())
+	}
+}
+
+func TestDecompressorZipRowErrorCap(t *testing.T) {
+	// The cap applies to the archive, not to each file
+	input := zipFile(t, "july.csv", decompressorCSV, "august.csv", decompressorCSV, "september.csv", decompressorCSV)
+	decompressor := NewDefaultReader(2024, "")
+	sequence, report := decompressor.ReadTransactions(context.Background(), entities.FileInfo{Source: "statements.zip"}, bytes.NewReader(input))
+	report.MaxRowErrors = 2
+	for _, err := range sequence {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(report.RowErrors) != 2 || report.OmittedRows != 1 || report.InvalidRows() != 3 {
+		t.Errorf("kept %d row errors and omitted %d rows, want 2 and 1", len(report.RowErrors), report.OmittedRows)
+	}
+}
@@ -0,0 +1,220 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"slices"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// maxJSONLine is the longest line accepted in a JSON Lines file. It keeps a
+// corrupt file from being buffered whole.
+const maxJSONLine = 1024 * 1024
+
+// JSONLReader implements the FileReader interface to read transactions from a
+// JSON Lines (newline-delimited JSON) file, one object per line.
+//
+// Object keys are matched to the same columns as the CSV header, so an object
+// such as {"date":"2024-07-15","amount":-10.3,"account_id":"1"} is read like
+// the equivalent CSV row. The amount may also be the {"amount","currency"}
+// object written by the JSON encoding of a transaction.
+type JSONLReader struct {
+	// StatementYear is the year applied to short M/DD dates. When zero the
+	// current year is used.
+	StatementYear int
+	// DefaultCurrency is used for objects without a currency.
+	// When empty entities.DefaultCurrency is used.
+	DefaultCurrency string
+}
+
+// Ensure JSONLReader implements interfaces.FileReader
+var _ interfaces.FileReader = &JSONLReader{}
+
+// NewJSONLReader creates a new JSONLReader instance.
+func NewJSONLReader() *JSONLReader {
+	return &JSONLReader{}
+}
+
+// JSONLFormat returns the JSON Lines format read by reader.
+func JSONLFormat(reader *JSONLReader) Format {
+	return Format{
+		Name:         "jsonl",
+		Extensions:   []string{".jsonl", ".ndjson"},
+		ContentTypes: []string{"application/jsonl", "application/x-ndjson", "application/x-jsonlines"},
+		Sniff:        sniffJSONL,
+		Reader:       reader,
+	}
+}
+
+// sniffJSONL reports whether head looks like the start of a JSON Lines file:
+// text that starts with an object.
+func sniffJSONL(head []byte) bool {
+	head = bytes.TrimLeft(head, "\ufeff \t\r\n")
+	return len(head) > 0 && head[0] == '{'
+}
+
+// ReadTransactions returns the transactions of a JSON Lines file, read one line
+// at a time while the sequence is iterated. Transactions without an id get one
+// derived from the file source and the line. Lines that are not valid JSON
+// objects or fail validation are left out of the sequence and described in
+// the returned report.
+func (r *JSONLReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	source := file.Source
+	report := &entities.ValidationReport{
+		Source: source,
+		Header: columnNames,
+	}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLine)
+
+		header := make(header, len(columnNames))
+		for i, name := range columnNames {
+			header[name] = i
+		}
+		parser := recordParser{statementYear: r.StatementYear, defaultCurrency: r.DefaultCurrency}
+		valid := 0
+
+		for line := 1; scanner.Scan(); line++ {
+			// Stop reading a large file once the caller gives up
+			if err := ctx.Err(); err != nil {
+				yield(entities.Transaction{}, fmt.Errorf("could not read JSON Lines: %w", err))
+				return
+			}
+
+			text := bytes.TrimSpace(scanner.Bytes())
+			if line == 1 {
+				text = bytes.TrimPrefix(text, []byte("\ufeff"))
+			}
+			if len(text) == 0 {
+				continue
+			}
+
+			record, rowErrors := jsonlRecord(text)
+			if len(rowErrors) == 0 {
+				var transaction entities.Transaction
+				transaction, rowErrors = parser.parseRecord(source, line, header, record)
+				if len(rowErrors) == 0 {
+					transaction.Line = line
+					transaction.Record = record
+					if !yield(transaction, nil) {
+						return
+					}
+					valid++
+					continue
+				}
+			}
+			for i := range rowErrors {
+				rowErrors[i].Line = line
+				rowErrors[i].Record = record
+			}
+			report.Add(rowErrors...)
+		}
+		if err := scanner.Err(); err != nil {
+			log.Printf("Error reading JSON Lines: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read JSON Lines: %w", err))
+			return
+		}
+
+		log.Printf("JSON Lines file contains %d valid and %d invalid records", valid, report.InvalidRows())
+	}
+
+	return transactions, report
+}
+
+// jsonlRecord converts a JSON object to a record in the order of columnNames.
+// Unknown keys are ignored. The record is nil when the line is not an object.
+func jsonlRecord(line []byte) ([]string, []entities.RowError) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line, &object); err != nil {
+		return nil, []entities.RowError{{Reason: fmt.Sprintf("invalid JSON object: %v", err)}}
+	}
+
+	// Sort the keys so duplicate columns are always reported the same way
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	record := make([]string, len(columnNames))
+	seen := make(map[string]string)
+	var rowErrors []entities.RowError
+	for _, key := range keys {
+		known, ok := lookupColumn(key)
+		if !ok {
+			continue
+		}
+		if other, exists := seen[known.name]; exists {
+			rowErrors = append(rowErrors, entities.RowError{Column: known.name, Value: key, Reason: fmt.Sprintf("duplicate %s field, also set by %q", known.name, other)})
+			continue
+		}
+		seen[known.name] = key
+
+		index := slices.Index(columnNames, known.name)
+		raw := object[key]
+
+		// A transaction encoded as JSON holds its amount and currency in one object
+		if known.name == columnAmount.name && bytes.HasPrefix(raw, []byte("{")) {
+			var money struct {
+				Amount   json.RawMessage `json:"amount"`
+				Currency string          `json:"currency"`
+			}
+			if err := json.Unmarshal(raw, &money); err != nil {
+				rowErrors = append(rowErrors, entities.RowError{Column: known.name, Value: string(raw), Reason: "invalid transaction amount"})
+				continue
+			}
+			raw = money.Amount
+			if currency := slices.Index(columnNames, columnCurrency.name); record[currency] == "" {
+				record[currency] = money.Currency
+			}
+		}
+
+		value, err := jsonlValue(raw)
+		if err != nil {
+			rowErrors = append(rowErrors, entities.RowError{Column: known.name, Value: string(raw), Reason: err.Error()})
+			continue
+		}
+		if value != "" || record[index] == "" {
+			record[index] = value
+		}
+	}
+
+	return record, rowErrors
+}
+
+// jsonlValue returns the text of a JSON string, number or boolean. Numbers
+// keep their literal form, so amounts are never rounded through a float.
+// Null and missing values are empty.
+func jsonlValue(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '"':
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", fmt.Errorf("invalid string: %w", err)
+		}
+		return value, nil
+	case 'n':
+		return "", nil
+	case '{', '[':
+		return "", fmt.Errorf("expected a string or number")
+	default:
+		// Numbers and booleans were validated when the line was decoded
+		return strings.TrimSpace(string(raw)), nil
+	}
+}
@@ -0,0 +1,99 @@
+package file
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONLReaderReadTransactions(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantAmounts []int64  // Minor units of the valid transactions
+		wantDates   []string // Dates of the valid transactions
+		wantLines   []int    // Lines of the row errors
+		wantColumns []string // Columns of the row errors
+	}{
+		{
+			name: "numbers, strings and key aliases",
+			input: `{"date": "2024-07-15", "amount": 60.5, "account_id": "1"}` + "\n" +
+				`{"Date": "7/28", "Transaction": "-10.30", "AccountId": 1}` + "\n",
+			wantAmounts: []int64{6050, -1030},
+			wantDates:   []string{"2024-07-15", "2024-07-28"},
+		},
+		{
+			name:        "encoded transaction amount",
+			input:       `{"id": "x", "date": "2024-08-02T10:00:00Z", "amount": {"amount": "-20.46", "currency": "EUR"}, "account_id": "2"}` + "\n",
+			wantAmounts: []int64{-2046},
+			wantDates:   []string{"2024-08-02"},
+		},
+		{
+			name:        "blank lines are skipped",
+			input:       "\n" + `{"date": "2024-08-13", "amount": 10, "account_id": "1"}` + "\n\n",
+			wantAmounts: []int64{1000},
+			wantDates:   []string{"2024-08-13"},
+		},
+		{
+			name: "invalid lines are reported",
+			input: `not json` + "\n" +
+				`{"date": "2024-07-15", "amount": 1.234, "account_id": "1"}` + "\n" +
+				`{"date": "2024-07-15", "amount": [1], "account_id": "1"}` + "\n" +
+				`{"account": "1", "account_id": "2", "date": "2024-07-15", "amount": 1}` + "\n" +
+				`{"date": "2024-07-15", "amount": 1, "account_id": "1", "merchant": "` + strings.Repeat("a", 256) + `"}` + "\n" +
+				`{"date": "2024-07-15", "amount": 1, "account_id": "1"}` + "\n",
+			wantAmounts: []int64{100},
+			wantDates:   []string{"2024-07-15"},
+			wantLines:   []int{1, 2, 3, 4, 5},
+			wantColumns: []string{"", "Amount", "Amount", "AccountId", "Merchant"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, report, err := read(&JSONLReader{StatementYear: 2024}, "test", tt.input)
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+
+			var amounts []int64
+			var dates []string
+			for _, transaction := range transactions {
+				amounts = append(amounts, transaction.Amount.Amount)
+				dates = append(dates, transaction.TransactionDate.Format("2006-01-02"))
+				if transaction.Line == 0 || len(transaction.Record) != len(columnNames) {
+					t.Errorf("transaction %s has line %d and record %q", transaction.ID, transaction.Line, transaction.Record)
+				}
+			}
+			if !slices.Equal(amounts, tt.wantAmounts) || !slices.Equal(dates, tt.wantDates) {
+				t.Errorf("read %v on %v, want %v on %v", amounts, dates, tt.wantAmounts, tt.wantDates)
+			}
+
+			var lines []int
+			var columns []string
+			for _, rowError := range report.RowErrors {
+				lines = append(lines, rowError.Line)
+				columns = append(columns, rowError.Column)
+			}
+			if !slices.Equal(lines, tt.wantLines) || !slices.Equal(columns, tt.wantColumns) {
+				t.Errorf("row errors on lines %v in %v, want %v in %v", lines, columns, tt.wantLines, tt.wantColumns)
+			}
+		})
+	}
+}
+
+func TestJSONLReaderFixture(t *testing.T) {
+	input, err := os.ReadFile("../../../testData/transactions.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, report, err := read(NewJSONLReader(), "transactions.jsonl", string(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasErrors() || len(transactions) != 7 {
+		t.Fatalf("read %d transactions and row errors %v, want 7 and none", len(transactions), report.RowErrors)
+	}
+}
@@ -0,0 +1,61 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// LocalErrorReporter implements the ErrorReporter interface by writing the
+// reports next to a local file, as <file>.errors.csv and <file>.quarantine.csv.
+type LocalErrorReporter struct {
+	// Dir is used for sources that are not local files, such as stdin.
+	Dir string
+}
+
+// Ensure LocalErrorReporter implements interfaces.ErrorReporter
+var _ interfaces.ErrorReporter = &LocalErrorReporter{}
+
+// NewLocalErrorReporter creates a new LocalErrorReporter instance.
+func NewLocalErrorReporter(dir string) *LocalErrorReporter {
+	return &LocalErrorReporter{Dir: dir}
+}
+
+// ReportErrors writes the error report next to the source file.
+func (r *LocalErrorReporter) ReportErrors(ctx context.Context, report *entities.ValidationReport) error {
+	return r.write(r.path(report.Source, ".errors.csv"), report, WriteErrorReport)
+}
+
+// Quarantine writes the invalid rows next to the source file.
+func (r *LocalErrorReporter) Quarantine(ctx context.Context, report *entities.ValidationReport) error {
+	return r.write(r.path(report.Source, ".quarantine.csv"), report, WriteQuarantine)
+}
+
+func (r *LocalErrorReporter) write(path string, report *entities.ValidationReport, writeFn func(w io.Writer, report *entities.ValidationReport) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := writeFn(f, report); err != nil {
+		return err
+	}
+	log.Printf("Wrote %s", path)
+	return f.Close()
+}
+
+// path returns the report path for a source, e.g. "file:///data/in.csv" -> "/data/in.csv.errors.csv".
+func (r *LocalErrorReporter) path(source, suffix string) string {
+	if path, ok := strings.CutPrefix(source, "file://"); ok {
+		return path + suffix
+	}
+	return filepath.Join(r.Dir, filepath.Base(source)+suffix)
+}
@@ -0,0 +1,373 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"iter"
+	"log"
+	"strings"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// maxOFXValue is the longest tag or value accepted in an OFX file. It keeps a
+// corrupt file from being buffered whole.
+const maxOFXValue = 64 * 1024
+
+// OFXReader implements the FileReader interface to read transactions from OFX
+// and QFX bank statements, both OFX 1.x (SGML) and 2.x (XML).
+//
+// Every STMTTRN becomes a transaction of the account in the BANKACCTFROM (or
+// CCACCTFROM) of its statement, in the statement currency (CURDEF).
+type OFXReader struct {
+	// DefaultCurrency is used for statements without a CURDEF.
+	// When empty entities.DefaultCurrency is used.
+	DefaultCurrency string
+}
+
+// Ensure OFXReader implements interfaces.FileReader
+var _ interfaces.FileReader = &OFXReader{}
+
+// NewOFXReader creates a new OFXReader instance.
+func NewOFXReader() *OFXReader {
+	return &OFXReader{}
+}
+
+// OFXFormat returns the OFX format read by reader, which also covers QFX files.
+func OFXFormat(reader *OFXReader) Format {
+	return Format{
+		Name:         "ofx",
+		Extensions:   []string{".ofx", ".qfx"},
+		ContentTypes: []string{"application/x-ofx", "application/ofx", "application/vnd.intu.qfx"},
+		Sniff:        sniffOFX,
+		Reader:       reader,
+	}
+}
+
+// sniffOFX reports whether head looks like the start of an OFX file: an OFX
+// 1.x header, an OFX 2.x processing instruction or the OFX root element.
+func sniffOFX(head []byte) bool {
+	upper := bytes.ToUpper(head)
+	return bytes.Contains(upper, []byte("OFXHEADER")) || bytes.Contains(upper, []byte("<OFX>"))
+}
+
+// ofxStatement holds the statement values that apply to its transactions.
+type ofxStatement struct {
+	account  string
+	currency string
+}
+
+// ReadTransactions returns the transactions of an OFX file, read one tag at a
+// time while the sequence is iterated. Transaction IDs are derived from the
+// account and the FITID, so statements exported for overlapping periods do not
+// duplicate transactions. Entries that fail validation are left out of the
+// sequence and described in the returned report.
+func (r *OFXReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	report := &entities.ValidationReport{
+		Source: file.Source,
+		Header: columnNames,
+	}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		scanner := &ofxScanner{reader: bufio.NewReader(input), line: 1}
+		var statement ofxStatement
+		var fields map[string]string // Fields of the open STMTTRN, nil outside one
+		var fieldsLine int
+		var lastOpen string // Tag whose value may follow
+		inAccount := false
+		seenOFX := false
+		valid := 0
+
+		for {
+			// Stop reading a large file once the caller gives up
+			if err := ctx.Err(); err != nil {
+				yield(entities.Transaction{}, fmt.Errorf("could not read OFX: %w", err))
+				return
+			}
+
+			token, err := scanner.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Printf("Error reading OFX: %v", err)
+				yield(entities.Transaction{}, fmt.Errorf("could not read OFX: %w", err))
+				return
+			}
+
+			switch token.kind {
+			case ofxOpen:
+				lastOpen = token.value
+				switch token.value {
+				case "OFX":
+					seenOFX = true
+				case "STMTRS", "CCSTMTRS":
+					statement = ofxStatement{}
+				case "BANKACCTFROM", "CCACCTFROM":
+					inAccount = true
+				case "STMTTRN":
+					fields = make(map[string]string)
+					fieldsLine = token.line
+				}
+
+			case ofxClose:
+				lastOpen = ""
+				switch token.value {
+				case "BANKACCTFROM", "CCACCTFROM":
+					inAccount = false
+				case "STMTTRN":
+					transaction, rowErrors := r.parseTransaction(statement, fields)
+					record := ofxRecord(statement, fields)
+					fields = nil
+					if len(rowErrors) > 0 {
+						for i := range rowErrors {
+							rowErrors[i].Line = fieldsLine
+							rowErrors[i].Record = record
+						}
+						report.Add(rowErrors...)
+						continue
+					}
+					transaction.Line = fieldsLine
+					transaction.Record = record
+					if !yield(transaction, nil) {
+						return
+					}
+					valid++
+				}
+
+			case ofxText:
+				// In OFX 1.x leaf elements have no end tag, so a value is
+				// the text that follows an open tag
+				if lastOpen == "" {
+					continue
+				}
+				switch {
+				case fields != nil:
+					if _, exists := fields[lastOpen]; !exists {
+						fields[lastOpen] = token.value
+					}
+				case inAccount && lastOpen == "ACCTID":
+					statement.account = token.value
+				case lastOpen == "CURDEF":
+					statement.currency = token.value
+				}
+				lastOpen = ""
+			}
+		}
+
+		if !seenOFX {
+			yield(entities.Transaction{}, fmt.Errorf("could not read OFX: no OFX element found"))
+			return
+		}
+		log.Printf("OFX file contains %d valid and %d invalid transactions", valid, report.InvalidRows())
+	}
+
+	return transactions, report
+}
+
+// parseTransaction validates the fields of a STMTTRN and converts them to a
+// transaction. Every invalid field is reported, not just the first one.
+func (r *OFXReader) parseTransaction(statement ofxStatement, fields map[string]string) (entities.Transaction, []entities.RowError) {
+	var rowErrors []entities.RowError
+
+	fitID := fields["FITID"]
+	if fitID == "" {
+		rowErrors = append(rowErrors, entities.RowError{Column: "FITID", Reason: "missing FITID"})
+	}
+	if statement.account == "" {
+		rowErrors = append(rowErrors, entities.RowError{Column: "ACCTID", Reason: "missing account id in BANKACCTFROM"})
+	}
+
+	currency, err := parseCurrency(statement.currency, r.DefaultCurrency)
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: "CURDEF", Value: statement.currency, Reason: err.Error()})
+	}
+
+	amount, err := entities.ParseMoney(normalizeOFXAmount(fields["TRNAMT"]), currency)
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: "TRNAMT", Value: fields["TRNAMT"], Reason: "invalid transaction amount"})
+	}
+
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: "DTPOSTED", Value: fields["DTPOSTED"], Reason: err.Error()})
+	}
+
+	// The text must fit the database columns
+	rowErrors = checkTextLength(rowErrors, "MEMO", fields["MEMO"])
+	rowErrors = checkTextLength(rowErrors, "NAME", fields["NAME"])
+
+	if len(rowErrors) > 0 {
+		return entities.Transaction{}, rowErrors
+	}
+
+	return entities.Transaction{
+		ID:              providedTransactionID(statement.account, fitID),
+		AccountID:       statement.account,
+		Amount:          amount,
+		TransactionDate: date,
+		Type:            determineTransactionType(amount),
+		Description:     fields["MEMO"],
+		Merchant:        fields["NAME"],
+	}, nil
+}
+
+// ofxRecord returns the values of a STMTTRN as a CSV row in the order of
+// columnNames, so a quarantined transaction can be fixed and uploaded again
+// as a CSV file. Its FITID becomes the Id, which derives the same transaction
+// ID, and a valid DTPOSTED is written as YYYY-MM-DD.
+func ofxRecord(statement ofxStatement, fields map[string]string) []string {
+	date := fields["DTPOSTED"]
+	if parsed, err := parseOFXDate(date); err == nil {
+		date = parsed.Format("2006-01-02")
+	}
+	values := map[string]string{
+		columnID.name:          fields["FITID"],
+		columnDate.name:        date,
+		columnAmount.name:      fields["TRNAMT"],
+		columnAccount.name:     statement.account,
+		columnDescription.name: fields["MEMO"],
+		columnMerchant.name:    fields["NAME"],
+		columnCurrency.name:    statement.currency,
+	}
+	record := make([]string, len(columnNames))
+	for i, name := range columnNames {
+		record[i] = values[name]
+	}
+	return record
+}
+
+// normalizeOFXAmount accepts a comma as the decimal separator, which OFX
+// allows, and drops trailing zeros past the second decimal place.
+func normalizeOFXAmount(value string) string {
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	if whole, fraction, found := strings.Cut(value, "."); found && len(fraction) > 2 {
+		value = whole + "." + fraction[:2] + strings.TrimRight(fraction[2:], "0")
+	}
+	return value
+}
+
+// parseOFXDate parses the date of an OFX datetime such as "20240115",
+// "20240115120000" or "20240115120000.000[-5:EST]". The time and time zone
+// are ignored, since transactions are dated by day.
+func parseOFXDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing date")
+	}
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date format, expected YYYYMMDD")
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+	return date, nil
+}
+
+// ofxTokenKind is the kind of an OFX token.
+type ofxTokenKind int
+
+const (
+	ofxOpen  ofxTokenKind = iota // Start tag; value is the upper-case name
+	ofxClose                     // End tag; value is the upper-case name
+	ofxText                      // Text between tags; value is trimmed and unescaped
+)
+
+// ofxToken is a tag or a piece of text of an OFX file.
+type ofxToken struct {
+	kind  ofxTokenKind
+	value string
+	line  int
+}
+
+// ofxScanner splits an OFX file into tags and text. It understands both the
+// SGML of OFX 1.x, where leaf elements have no end tag, and the XML of OFX
+// 2.x. Headers, processing instructions and comments are skipped.
+type ofxScanner struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// next returns the next tag or non-blank text.
+func (s *ofxScanner) next() (ofxToken, error) {
+	for {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return ofxToken{}, err
+		}
+
+		if b != '<' {
+			s.reader.UnreadByte()
+			line := s.line
+			text, err := s.readUntil('<', false)
+			if err != nil && err != io.EOF {
+				return ofxToken{}, err
+			}
+			if value := strings.TrimSpace(text); value != "" {
+				return ofxToken{kind: ofxText, value: html.UnescapeString(value), line: line}, nil
+			}
+			if err == io.EOF {
+				return ofxToken{}, io.EOF
+			}
+			continue
+		}
+
+		line := s.line
+		tag, err := s.readUntil('>', true)
+		if err == io.EOF {
+			return ofxToken{}, fmt.Errorf("line %d: unterminated tag", line)
+		}
+		if err != nil {
+			return ofxToken{}, err
+		}
+
+		// Skip <?xml ...?>, <?OFX ...?>, <!-- ... --> and empty elements
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag[0] == '?' || tag[0] == '!' || strings.HasSuffix(tag, "/") {
+			continue
+		}
+		if name, closing := strings.CutPrefix(tag, "/"); closing {
+			return ofxToken{kind: ofxClose, value: tagName(name), line: line}, nil
+		}
+		return ofxToken{kind: ofxOpen, value: tagName(tag), line: line}, nil
+	}
+}
+
+// readUntil reads up to delim, consuming it when consume is set. It fails
+// when the text grows past maxOFXValue.
+func (s *ofxScanner) readUntil(delim byte, consume bool) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		if b == delim {
+			if !consume {
+				s.reader.UnreadByte()
+			}
+			return sb.String(), nil
+		}
+		if b == '\n' {
+			s.line++
+		}
+		if sb.Len() >= maxOFXValue {
+			return "", fmt.Errorf("line %d: value longer than %d bytes", s.line, maxOFXValue)
+		}
+		sb.WriteByte(b)
+	}
+}
+
+// tagName returns the upper-case name of a tag, without attributes.
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, " ")
+	return strings.ToUpper(strings.TrimSpace(name))
+}
@@ -0,0 +1,148 @@
+package file
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ofxStatementFile wraps STMTTRN entries in an OFX 1.x bank statement of account.
+func ofxStatementFile(account, currency, entries string) string {
+	return "OFXHEADER:100\nDATA:OFXSGML\n\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>\n<CURDEF>" + currency +
+		"\n<BANKACCTFROM><BANKID>1<ACCTID>" + account + "<ACCTTYPE>CHECKING</BANKACCTFROM>\n<BANKTRANLIST>\n" +
+		entries + "</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n"
+}
+
+func TestOFXReaderReadTransactions(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantAmounts []int64  // Minor units of the valid transactions
+		wantDates   []string // Dates of the valid transactions
+		wantColumns []string // Columns of the row errors
+		wantErr     string
+	}{
+		{
+			name: "SGML entries without end tags",
+			input: ofxStatementFile("1", "USD",
+				"<STMTTRN>\n<DTPOSTED>20240715120000.000[-5:EST]\n<TRNAMT>60.50\n<FITID>A1\n</STMTTRN>\n"+
+					"<STMTTRN>\n<DTPOSTED>20240728\n<TRNAMT>-10,3\n<FITID>A2\n</STMTTRN>\n"),
+			wantAmounts: []int64{6050, -1030},
+			wantDates:   []string{"2024-07-15", "2024-07-28"},
+		},
+		{
+			name: "XML entries with end tags",
+			input: ofxStatementFile("2", "EUR",
+				"<STMTTRN><DTPOSTED>20240802</DTPOSTED><TRNAMT>-20.4600</TRNAMT><FITID>B1</FITID></STMTTRN>\n"),
+			wantAmounts: []int64{-2046},
+			wantDates:   []string{"2024-08-02"},
+		},
+		{
+			name: "invalid entries are reported per field",
+			input: ofxStatementFile("1", "USD",
+				"<STMTTRN>\n<DTPOSTED>2024\n<TRNAMT>ten\n</STMTTRN>\n"+
+					"<STMTTRN>\n<DTPOSTED>20240813\n<TRNAMT>10.00\n<FITID>C1\n</STMTTRN>\n"),
+			wantAmounts: []int64{1000},
+			wantDates:   []string{"2024-08-13"},
+			wantColumns: []string{"FITID", "TRNAMT", "DTPOSTED"},
+		},
+		{
+			name:        "missing account",
+			input:       ofxStatementFile("", "USD", "<STMTTRN>\n<DTPOSTED>20240813\n<TRNAMT>10.00\n<FITID>C1\n</STMTTRN>\n"),
+			wantColumns: []string{"ACCTID"},
+		},
+		{
+			name:        "text longer than the database columns",
+			input:       ofxStatementFile("1", "USD", "<STMTTRN>\n<DTPOSTED>20240813\n<TRNAMT>10.00\n<FITID>C1\n<NAME>"+strings.Repeat("a", 256)+"\n</STMTTRN>\n"),
+			wantColumns: []string{"NAME"},
+		},
+		{
+			name:    "not an OFX file",
+			input:   "just some text\n",
+			wantErr: "no OFX element found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, report, err := read(NewOFXReader(), "test", tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadTransactions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+
+			var amounts []int64
+			var dates []string
+			for _, transaction := range transactions {
+				amounts = append(amounts, transaction.Amount.Amount)
+				dates = append(dates, transaction.TransactionDate.Format("2006-01-02"))
+				if transaction.Line == 0 || len(transaction.Record) != len(columnNames) {
+					t.Errorf("transaction %s has line %d and record %q", transaction.ID, transaction.Line, transaction.Record)
+				}
+			}
+			if !slices.Equal(amounts, tt.wantAmounts) || !slices.Equal(dates, tt.wantDates) {
+				t.Errorf("read %v on %v, want %v on %v", amounts, dates, tt.wantAmounts, tt.wantDates)
+			}
+
+			var columns []string
+			for _, rowError := range report.RowErrors {
+				columns = append(columns, rowError.Column)
+				if rowError.Line != 8 {
+					t.Errorf("row error %s on line %d, want line 8", rowError.Column, rowError.Line)
+				}
+			}
+			if !slices.Equal(columns, tt.wantColumns) {
+				t.Errorf("row errors in %v, want %v", columns, tt.wantColumns)
+			}
+		})
+	}
+}
+
+func TestOFXReaderQuarantinedRecord(t *testing.T) {
+	input := ofxStatementFile("1", "USD", "<STMTTRN>\n<DTPOSTED>20240715\n<TRNAMT>1.234\n<FITID>A1\n<NAME>Shop\n<MEMO>Lunch\n</STMTTRN>\n")
+	_, report, err := read(NewOFXReader(), "test", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.RowErrors) != 1 {
+		t.Fatalf("got %d row errors, want 1: %v", len(report.RowErrors), report.RowErrors)
+	}
+
+	// The record is a CSV row that can be uploaded again once fixed, and
+	// derives the same transaction ID
+	want := []string{"A1", "2024-07-15", "1.234", "1", "Lunch", "Shop", "USD"}
+	if got := report.RowErrors[0].Record; !slices.Equal(got, want) {
+		t.Errorf("record = %q, want %q", got, want)
+	}
+	if !slices.Equal(report.Header, columnNames) {
+		t.Errorf("header = %q, want %q", report.Header, columnNames)
+	}
+	fixed := readAll(t, "other.csv", strings.Join(report.Header, ",")+"\nA1,2024-07-15,1.23,1,Lunch,Shop,USD\n")
+	if fixed[0].ID != providedTransactionID("1", "A1") {
+		t.Errorf("fixed row has ID %s, want the ID of FITID A1", fixed[0].ID)
+	}
+}
+
+func TestOFXReaderFixture(t *testing.T) {
+	input, err := os.ReadFile("../../../testData/statement.ofx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, report, err := read(NewOFXReader(), "statement.ofx", string(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.HasErrors() || len(transactions) != 4 {
+		t.Fatalf("read %d transactions and row errors %v, want 4 and none", len(transactions), report.RowErrors)
+	}
+	if transactions[1].Merchant != "Coffee & Co" {
+		t.Errorf("merchant = %q, want %q", transactions[1].Merchant, "Coffee & Co")
+	}
+}
@@ -0,0 +1,319 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// parquetBatchSize is the number of rows read from a Parquet file at a time.
+const parquetBatchSize = 256
+
+// julianUnixEpoch is the Julian day of 1970-01-01, the epoch of INT96 timestamps.
+const julianUnixEpoch = 2440588
+
+// defaultMaxSpoolSize is the largest input copied to a temporary file to be
+// read at random.
+const defaultMaxSpoolSize = 1 << 30 // 1 GiB
+
+// ParquetReader implements the FileReader interface to read transactions from
+// a Parquet file.
+//
+// Columns are matched by name to the same columns as the CSV header, so a
+// file with date, amount and account_id columns is read like the equivalent
+// CSV file. Amounts may be strings, integers, decimals or floating point
+// numbers, which are never rounded: a float that is not exact to the cent
+// makes the row invalid. Dates may be strings, DATE or TIMESTAMP values.
+type ParquetReader struct {
+	// StatementYear is the year applied to short M/DD dates. When zero the
+	// current year is used.
+	StatementYear int
+	// DefaultCurrency is used for rows without a currency column or value.
+	// When empty entities.DefaultCurrency is used.
+	DefaultCurrency string
+	// MaxSize is the largest input copied to a temporary file when it cannot
+	// be read at random. Zero means no limit.
+	MaxSize int64
+}
+
+// Ensure ParquetReader implements interfaces.FileReader
+var _ interfaces.FileReader = &ParquetReader{}
+
+// NewParquetReader creates a new ParquetReader instance.
+func NewParquetReader() *ParquetReader {
+	return &ParquetReader{MaxSize: defaultMaxSpoolSize}
+}
+
+// ParquetFormat returns the Parquet format read by reader.
+func ParquetFormat(reader *ParquetReader) Format {
+	return Format{
+		Name:         "parquet",
+		Extensions:   []string{".parquet"},
+		ContentTypes: []string{"application/vnd.apache.parquet", "application/x-parquet"},
+		Sniff:        sniffParquet,
+		Reader:       reader,
+	}
+}
+
+// sniffParquet reports whether head starts with the Parquet magic number.
+func sniffParquet(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("PAR1"))
+}
+
+// ReadTransactions returns the transactions of a Parquet file, read a batch
+// of rows at a time while the sequence is iterated. Parquet files keep their
+// metadata at the end, so an input that cannot be read at random, such as an
+// S3 object, is first copied to a temporary file. Transactions without an id
+// get one derived from the file source and the row number. Rows that fail
+// validation are left out of the sequence and described in the returned
+// report, with their values as text so they can be quarantined as CSV.
+func (r *ParquetReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	source := file.Source
+	report := &entities.ValidationReport{Source: source}
+
+	transactions := func(yield func(entities.Transaction, error) bool) {
+		readerAt, size, cleanup, err := randomAccess(input, "tsummary-*.parquet", r.MaxSize)
+		if err != nil {
+			log.Printf("Error reading Parquet: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read Parquet: %w", err))
+			return
+		}
+		defer cleanup()
+
+		parquetFile, err := parquet.OpenFile(readerAt, size)
+		if err != nil {
+			log.Printf("Error reading Parquet: %v", err)
+			yield(entities.Transaction{}, fmt.Errorf("could not read Parquet: %w", err))
+			return
+		}
+
+		// Map the columns by name, e.g. id, date, amount, account_id
+		schema := parquetFile.Schema()
+		names := make([]string, len(schema.Columns()))
+		formatters := make([]func(parquet.Value) string, len(names))
+		for i, path := range schema.Columns() {
+			names[i] = strings.Join(path, ".")
+			leaf, _ := schema.Lookup(path...)
+			formatters[i] = parquetFormatter(leaf.Node.Type())
+		}
+		header, err := parseHeader("Parquet", names)
+		if err != nil {
+			yield(entities.Transaction{}, err)
+			return
+		}
+		report.Header = names
+
+		reader := parquet.NewReader(parquetFile)
+		defer reader.Close()
+
+		parser := recordParser{statementYear: r.StatementYear, defaultCurrency: r.DefaultCurrency}
+		rows := make([]parquet.Row, parquetBatchSize)
+		valid := 0
+		row := 0
+		for {
+			// Stop reading a large file once the caller gives up
+			if err := ctx.Err(); err != nil {
+				yield(entities.Transaction{}, fmt.Errorf("could not read Parquet: %w", err))
+				return
+			}
+
+			n, readErr := reader.ReadRows(rows)
+			for _, values := range rows[:n] {
+				row++
+				record := parquetRecord(values, formatters)
+				transaction, rowErrors := parser.parseRecord(source, row, header, record)
+				if len(rowErrors) > 0 {
+					for i := range rowErrors {
+						rowErrors[i].Line = row
+						rowErrors[i].Record = record
+					}
+					report.Add(rowErrors...)
+					continue
+				}
+				transaction.Line = row
+				transaction.Record = record
+				if !yield(transaction, nil) {
+					return
+				}
+				valid++
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				log.Printf("Error reading Parquet: %v", readErr)
+				yield(entities.Transaction{}, fmt.Errorf("could not read Parquet: %w", readErr))
+				return
+			}
+		}
+
+		log.Printf("Parquet file contains %d valid and %d invalid rows", valid, report.InvalidRows())
+	}
+
+	return transactions, report
+}
+
+// randomAccess returns input as an io.ReaderAt and its size. Inputs that
+// cannot be read at random are copied to a temporary file named after
+// pattern, which cleanup removes. The copy fails once it passes maxSize
+// bytes, unless maxSize is zero.
+func randomAccess(input io.Reader, pattern string, maxSize int64) (io.ReaderAt, int64, func(), error) {
+	if seekable, ok := input.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := seekable.Seek(0, io.SeekEnd)
+		if err == nil {
+			return seekable, size, func() {}, nil
+		}
+	}
+
+	temp, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		temp.Close()
+		os.Remove(temp.Name())
+	}
+	if maxSize > 0 {
+		// One byte past the limit tells a file of exactly maxSize from a larger one
+		input = io.LimitReader(input, maxSize+1)
+	}
+	size, err := io.Copy(temp, input)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	if maxSize > 0 && size > maxSize {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("file is larger than the limit of %d bytes", maxSize)
+	}
+	return temp, size, cleanup, nil
+}
+
+// parquetRecord returns the values of a row as text, one per leaf column.
+// Only the first value of a repeated column is kept.
+func parquetRecord(values parquet.Row, formatters []func(parquet.Value) string) []string {
+	record := make([]string, len(formatters))
+	seen := make([]bool, len(formatters))
+	for _, value := range values {
+		column := value.Column()
+		if column < 0 || column >= len(record) || seen[column] {
+			continue
+		}
+		seen[column] = true
+		if !value.IsNull() {
+			record[column] = formatters[column](value)
+		}
+	}
+	return record
+}
+
+// parquetFormatter returns a function that writes the values of a column of
+// type t as text the record parser accepts: dates and timestamps as
+// YYYY-MM-DD, decimals with their scale and numbers without exponent.
+func parquetFormatter(t parquet.Type) func(parquet.Value) string {
+	logical := t.LogicalType()
+	switch {
+	case logical != nil && logical.Date != nil:
+		return func(v parquet.Value) string {
+			return time.Unix(int64(v.Int32())*24*60*60, 0).UTC().Format("2006-01-02")
+		}
+	case logical != nil && logical.Timestamp != nil:
+		unit := logical.Timestamp.Unit
+		return func(v parquet.Value) string {
+			var timestamp time.Time
+			switch {
+			case unit.Millis != nil:
+				timestamp = time.UnixMilli(v.Int64())
+			case unit.Micros != nil:
+				timestamp = time.UnixMicro(v.Int64())
+			default:
+				timestamp = time.Unix(0, v.Int64())
+			}
+			return timestamp.UTC().Format("2006-01-02")
+		}
+	case logical != nil && logical.Decimal != nil:
+		scale := int(logical.Decimal.Scale)
+		return func(v parquet.Value) string {
+			var unscaled *big.Int
+			switch v.Kind() {
+			case parquet.Int32:
+				unscaled = big.NewInt(int64(v.Int32()))
+			case parquet.Int64:
+				unscaled = big.NewInt(v.Int64())
+			default:
+				unscaled = twosComplement(v.ByteArray())
+			}
+			return formatDecimal(unscaled, scale)
+		}
+	}
+
+	switch t.Kind() {
+	case parquet.Boolean:
+		return func(v parquet.Value) string { return strconv.FormatBool(v.Boolean()) }
+	case parquet.Int32:
+		return func(v parquet.Value) string { return strconv.FormatInt(int64(v.Int32()), 10) }
+	case parquet.Int64:
+		return func(v parquet.Value) string { return strconv.FormatInt(v.Int64(), 10) }
+	case parquet.Int96:
+		// Legacy timestamps: nanoseconds of the day and a Julian day
+		return func(v parquet.Value) string {
+			day := int64(v.Int96()[2]) - julianUnixEpoch
+			return time.Unix(day*24*60*60, 0).UTC().Format("2006-01-02")
+		}
+	case parquet.Float:
+		// The shortest text that reads back as the same float, so 0.1 + 0.2
+		// is written as 0.30000000000000004 and rejected instead of rounded
+		return func(v parquet.Value) string { return strconv.FormatFloat(float64(v.Float()), 'f', -1, 32) }
+	case parquet.Double:
+		return func(v parquet.Value) string { return strconv.FormatFloat(v.Double(), 'f', -1, 64) }
+	default:
+		return func(v parquet.Value) string { return string(v.ByteArray()) }
+	}
+}
+
+// twosComplement decodes a big-endian two's complement integer, the encoding
+// of decimals stored as byte arrays.
+func twosComplement(b []byte) *big.Int {
+	value := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return value
+}
+
+// formatDecimal writes unscaled / 10^scale as a decimal. Zeros past the
+// second decimal place are dropped, so DECIMAL(18,4) amounts parse as money.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	if scale <= 0 {
+		return unscaled.String()
+	}
+	digits := new(big.Int).Abs(unscaled).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	fraction := digits[len(digits)-scale:]
+	if len(fraction) > 2 {
+		fraction = fraction[:2] + strings.TrimRight(fraction[2:], "0")
+	}
+	return sign + digits[:len(digits)-scale] + "." + fraction
+}
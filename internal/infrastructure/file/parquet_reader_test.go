@@ -0,0 +1,197 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"transactions-summary/internal/entities"
+)
+
+type parquetTransaction struct {
+	Date      string  `parquet:"date"`
+	Amount    float64 `parquet:"amount"`
+	AccountID string  `parquet:"account_id"`
+	Merchant  string  `parquet:"merchant"`
+}
+
+type parquetFloatTransaction struct {
+	Date      string  `parquet:"date"`
+	Amount    float32 `parquet:"amount"`
+	AccountID string  `parquet:"account_id"`
+}
+
+type parquetDecimalTransaction struct {
+	ID        string `parquet:"id"`
+	Date      int32  `parquet:"date,date"`
+	Amount    int64  `parquet:"amount,decimal(4:18)"`
+	AccountID int64  `parquet:"account_id"`
+}
+
+type parquetMissingAmount struct {
+	Date      string `parquet:"date"`
+	AccountID string `parquet:"account_id"`
+}
+
+// parquetFile writes rows to an in-memory Parquet file.
+func parquetFile[T any](t *testing.T, rows []T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatalf("could not write Parquet: %v", err)
+	}
+	return buf.String()
+}
+
+func TestParquetReaderReadTransactions(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantAmounts []int64  // Minor units of the valid transactions
+		wantDates   []string // Dates of the valid transactions
+		wantRows    []int    // Rows of the row errors
+		wantColumns []string // Columns of the row errors
+		wantErr     string
+	}{
+		{
+			name: "double amounts",
+			input: parquetFile(t, []parquetTransaction{
+				{Date: "2024-07-15", Amount: 60.5, AccountID: "1"},
+				{Date: "7/28", Amount: -10.3, AccountID: "1"},
+			}),
+			wantAmounts: []int64{6050, -1030},
+			wantDates:   []string{"2024-07-15", "2024-07-28"},
+		},
+		{
+			name:        "float amounts",
+			input:       parquetFile(t, []parquetFloatTransaction{{Date: "2024-07-15", Amount: -20.46, AccountID: "1"}}),
+			wantAmounts: []int64{-2046},
+			wantDates:   []string{"2024-07-15"},
+		},
+		{
+			name: "amounts not exact to the cent are not rounded",
+			input: parquetFile(t, []parquetTransaction{
+				{Date: "2024-07-15", Amount: 0.30000000000000004, AccountID: "1"},
+				{Date: "2024-07-15", Amount: 1.005, AccountID: "1"},
+				{Date: "2024-07-15", Amount: 0.3, AccountID: "1"},
+			}),
+			wantAmounts: []int64{30},
+			wantDates:   []string{"2024-07-15"},
+			wantRows:    []int{1, 2},
+			wantColumns: []string{"Amount", "Amount"},
+		},
+		{
+			name: "decimal amounts and date columns",
+			input: parquetFile(t, []parquetDecimalTransaction{
+				{ID: "A1", Date: 19919, Amount: -204600, AccountID: 2},
+			}),
+			wantAmounts: []int64{-2046},
+			wantDates:   []string{"2024-07-15"},
+		},
+		{
+			name: "invalid rows are reported",
+			input: parquetFile(t, []parquetTransaction{
+				{Date: "someday", Amount: 1, AccountID: "1"},
+				{Date: "2024-08-13", Amount: 10, AccountID: ""},
+				{Date: "2024-08-13", Amount: 10, AccountID: "1", Merchant: strings.Repeat("a", 256)},
+				{Date: "2024-08-13", Amount: 10, AccountID: "1"},
+			}),
+			wantAmounts: []int64{1000},
+			wantDates:   []string{"2024-08-13"},
+			wantRows:    []int{1, 2, 3},
+			wantColumns: []string{"Date", "AccountId", "Merchant"},
+		},
+		{
+			name:    "missing required column",
+			input:   parquetFile(t, []parquetMissingAmount{{Date: "2024-08-13", AccountID: "1"}}),
+			wantErr: "missing required Amount column",
+		},
+		{
+			name:    "not a Parquet file",
+			input:   "Date,Transaction,AccountId\n",
+			wantErr: "could not read Parquet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, report, err := read(&ParquetReader{StatementYear: 2024}, "test", tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadTransactions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadTransactions() error: %v", err)
+			}
+
+			var amounts []int64
+			var dates []string
+			for _, transaction := range transactions {
+				amounts = append(amounts, transaction.Amount.Amount)
+				dates = append(dates, transaction.TransactionDate.Format("2006-01-02"))
+				if transaction.Line == 0 || len(transaction.Record) != len(report.Header) {
+					t.Errorf("transaction %s has line %d and record %q", transaction.ID, transaction.Line, transaction.Record)
+				}
+			}
+			if !slices.Equal(amounts, tt.wantAmounts) || !slices.Equal(dates, tt.wantDates) {
+				t.Errorf("read %v on %v, want %v on %v", amounts, dates, tt.wantAmounts, tt.wantDates)
+			}
+
+			var rows []int
+			var columns []string
+			for _, rowError := range report.RowErrors {
+				rows = append(rows, rowError.Line)
+				columns = append(columns, rowError.Column)
+			}
+			if !slices.Equal(rows, tt.wantRows) || !slices.Equal(columns, tt.wantColumns) {
+				t.Errorf("row errors on rows %v in %v, want %v in %v", rows, columns, tt.wantRows, tt.wantColumns)
+			}
+		})
+	}
+}
+
+func TestParquetReaderRejectedFloatValue(t *testing.T) {
+	input := parquetFile(t, []parquetTransaction{{Date: "2024-07-15", Amount: 0.30000000000000004, AccountID: "1"}})
+	_, report, err := read(NewParquetReader(), "test", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.RowErrors) != 1 {
+		t.Fatalf("got %d row errors, want 1: %v", len(report.RowErrors), report.RowErrors)
+	}
+
+	// The report shows the float as stored, not a rounded amount
+	if got := report.RowErrors[0].Value; got != "0.30000000000000004" {
+		t.Errorf("value = %q, want %q", got, "0.30000000000000004")
+	}
+}
+
+func TestParquetReaderMaxSize(t *testing.T) {
+	input := parquetFile(t, []parquetTransaction{{Date: "2024-07-15", Amount: 60.5, AccountID: "1"}})
+
+	// An input that cannot be read at random is copied, up to MaxSize bytes
+	reader := NewParquetReader()
+	reader.MaxSize = int64(len(input)) - 1
+	sequence, _ := reader.ReadTransactions(context.Background(), entities.FileInfo{Source: "test"}, io.MultiReader(strings.NewReader(input)))
+	var err error
+	for _, err = range sequence {
+	}
+	if err == nil || !strings.Contains(err.Error(), "larger than the limit") {
+		t.Errorf("ReadTransactions() error = %v, want the size limit", err)
+	}
+
+	reader.MaxSize = int64(len(input))
+	sequence, _ = reader.ReadTransactions(context.Background(), entities.FileInfo{Source: "test"}, io.MultiReader(strings.NewReader(input)))
+	for _, err := range sequence {
+		if err != nil {
+			t.Errorf("ReadTransactions() error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"transactions-summary/internal/entities"
+)
+
+// recordParser converts records whose values are matched to columns by a
+// header into transactions. The CSV, JSON Lines and Parquet readers share it,
+// so every format is validated the same way.
+type recordParser struct {
+	statementYear   int    // Year of short M/DD dates, the current year when zero
+	defaultCurrency string // Currency of records without one
+}
+
+// parseRecord validates a single record and converts it to a transaction.
+// Every invalid value in the record is reported, not just the first one.
+func (p recordParser) parseRecord(source string, row int, header header, record []string) (entities.Transaction, []entities.RowError) {
+	var rowErrors []entities.RowError
+
+	id := header.value(record, columnID)
+	dateField := header.value(record, columnDate)
+	amountField := header.value(record, columnAmount)
+
+	// Parse AccountId
+	accountId := header.value(record, columnAccount)
+	if accountId == "" {
+		rowErrors = append(rowErrors, entities.RowError{Column: columnAccount.name, Reason: "missing account id"})
+	}
+
+	// Parse the currency (optional column, ISO 4217 code)
+	currency, err := parseCurrency(header.value(record, columnCurrency), p.defaultCurrency)
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: columnCurrency.name, Value: header.value(record, columnCurrency), Reason: err.Error()})
+	}
+
+	// Parse the transaction amount as an exact decimal
+	amount, err := entities.ParseMoney(amountField, currency)
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: columnAmount.name, Value: amountField, Reason: "invalid transaction amount"})
+	}
+
+	// Parse the date (YYYY-MM-DD, M/D/YYYY or M/DD in the statement year)
+	date, err := p.parseDate(dateField)
+	if err != nil {
+		rowErrors = append(rowErrors, entities.RowError{Column: columnDate.name, Value: dateField, Reason: err.Error()})
+	}
+
+	// The optional text must fit the database columns
+	description := header.value(record, columnDescription)
+	merchant := header.value(record, columnMerchant)
+	rowErrors = checkTextLength(rowErrors, columnDescription.name, description)
+	rowErrors = checkTextLength(rowErrors, columnMerchant.name, merchant)
+
+	if len(rowErrors) > 0 {
+		return entities.Transaction{}, rowErrors
+	}
+
+	// Derive the ID from the row content when the file does not provide one.
+	// A provided Id is only unique within its account, so it is namespaced.
+	if id == "" {
+		id = transactionID(source, row, dateField, amountField, accountId)
+	} else {
+		id = providedTransactionID(accountId, id)
+	}
+
+	// Create a transaction object
+	return entities.Transaction{
+		ID:              id,
+		AccountID:       accountId,
+		Amount:          amount,
+		TransactionDate: date,
+		Type:            determineTransactionType(amount),
+		Description:     description,
+		Merchant:        merchant,
+	}, nil
+}
+
+// parseDate parses a transaction date in YYYY-MM-DD, M/D/YYYY or M/DD format,
+// or an RFC 3339 timestamp such as "2024-07-15T00:00:00Z" whose time is
+// ignored. Short M/DD dates are placed in the statement year.
+func (p recordParser) parseDate(value string) (time.Time, error) {
+	var date time.Time
+	var err error
+
+	switch {
+	case value == "":
+		return time.Time{}, fmt.Errorf("missing date")
+	case strings.Contains(value, "T"):
+		date, err = time.Parse(time.RFC3339, value)
+		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	case strings.Contains(value, "-"):
+		date, err = time.Parse("2006-01-02", value)
+	case strings.Count(value, "/") == 2:
+		date, err = time.Parse("1/2/2006", value)
+	case strings.Count(value, "/") == 1:
+		year := p.statementYear
+		if year == 0 {
+			year = time.Now().Year()
+		}
+		date, err = time.Parse("1/2/2006", fmt.Sprintf("%s/%04d", value, year))
+	default:
+		return time.Time{}, fmt.Errorf("invalid date format, expected YYYY-MM-DD, M/D/YYYY or M/DD")
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+
+	return date, nil
+}
+
+// maxTextLength is the number of characters the description and merchant
+// columns can store.
+const maxTextLength = 255
+
+// checkTextLength adds a row error to rowErrors when value is longer than
+// maxTextLength characters.
+func checkTextLength(rowErrors []entities.RowError, column, value string) []entities.RowError {
+	if utf8.RuneCountInString(value) <= maxTextLength {
+		return rowErrors
+	}
+	return append(rowErrors, entities.RowError{
+		Column: column,
+		Value:  value,
+		Reason: fmt.Sprintf("longer than %d characters", maxTextLength),
+	})
+}
@@ -0,0 +1,154 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// sniffSize is the number of leading bytes handed to Format.Sniff.
+const sniffSize = 512
+
+// ErrUnknownFormat is yielded for a file whose format could not be detected.
+var ErrUnknownFormat = errors.New("could not detect the format")
+
+// Format describes a file format a Registry can read.
+type Format struct {
+	Name         string   // Name used to declare the format, e.g. "csv"
+	Extensions   []string // File extensions, e.g. ".csv"
+	ContentTypes []string // MIME types, e.g. "text/csv"
+	// Sniff reports whether the first bytes of a file look like this format.
+	// It may be nil when the format cannot be recognized by its content.
+	Sniff  func(head []byte) bool
+	Reader interfaces.FileReader
+}
+
+// Registry implements the FileReader interface by handing every file to the
+// reader of its format. The format is the declared one when set, otherwise the
+// one registered for the file extension, then for the content type, then the
+// first one whose Sniff accepts the start of the file.
+type Registry struct {
+	formats []Format
+}
+
+// Ensure Registry implements interfaces.FileReader
+var _ interfaces.FileReader = &Registry{}
+
+// NewRegistry creates a new Registry with the given formats. Formats are
+// sniffed in the order they are registered.
+func NewRegistry(formats ...Format) *Registry {
+	return &Registry{formats: formats}
+}
+
+// NewDefaultReader creates the reader used by the Lambda and the CLI, with
+// every supported format, compressed or not. statementYear applies to short M/DD dates, zero
+// meaning the current year; defaultCurrency applies to rows without a
+// currency, empty meaning entities.DefaultCurrency.
+func NewDefaultReader(statementYear int, defaultCurrency string) *Decompressor {
+	csvReader := NewCSVReader()
+	csvReader.StatementYear = statementYear
+	csvReader.DefaultCurrency = defaultCurrency
+	ofxReader := NewOFXReader()
+	ofxReader.DefaultCurrency = defaultCurrency
+	jsonlReader := NewJSONLReader()
+	jsonlReader.StatementYear = statementYear
+	jsonlReader.DefaultCurrency = defaultCurrency
+	parquetReader := NewParquetReader()
+	parquetReader.StatementYear = statementYear
+	parquetReader.DefaultCurrency = defaultCurrency
+
+	registry := NewRegistry(CSVFormat(csvReader), OFXFormat(ofxReader), JSONLFormat(jsonlReader), ParquetFormat(parquetReader))
+	return NewDecompressor(registry)
+}
+
+// Register adds a format to the registry.
+func (r *Registry) Register(format Format) {
+	r.formats = append(r.formats, format)
+}
+
+// Names returns the names of the registered formats.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.formats))
+	for i, format := range r.formats {
+		names[i] = format.Name
+	}
+	return names
+}
+
+// ReadTransactions detects the format of the file and reads it with the
+// reader of that format. A file of an unknown format yields a single error.
+// The input is handed to the reader as is unless its first bytes had to be
+// sniffed, so a reader can still use it as an io.ReaderAt, e.g. an *os.File.
+func (r *Registry) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	format, err := r.detect(file)
+	if err == nil && format == nil {
+		buffered := bufio.NewReaderSize(input, sniffSize)
+		input = buffered
+		format, err = r.sniff(file, buffered)
+	}
+	if err != nil {
+		return func(yield func(entities.Transaction, error) bool) {
+			yield(entities.Transaction{}, err)
+		}, &entities.ValidationReport{Source: file.Source}
+	}
+
+	log.Printf("Reading %s as %s", file.Source, format.Name)
+	return format.Reader.ReadTransactions(ctx, file, input)
+}
+
+// detect chooses the format of a file from its declared format, extension or
+// content type. It returns no format when none of them match.
+func (r *Registry) detect(file entities.FileInfo) (*Format, error) {
+	if file.Format != "" {
+		for i := range r.formats {
+			if strings.EqualFold(r.formats[i].Name, file.Format) {
+				return &r.formats[i], nil
+			}
+		}
+		return nil, fmt.Errorf("unknown file format %q (expected one of %s)", file.Format, strings.Join(r.Names(), ", "))
+	}
+
+	if extension := strings.ToLower(path.Ext(file.Source)); extension != "" {
+		for i := range r.formats {
+			if slices.Contains(r.formats[i].Extensions, extension) {
+				return &r.formats[i], nil
+			}
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(file.ContentType); err == nil {
+		for i := range r.formats {
+			if slices.Contains(r.formats[i].ContentTypes, mediaType) {
+				return &r.formats[i], nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// sniff chooses the format of a file by peeking at its first bytes.
+func (r *Registry) sniff(file entities.FileInfo, input *bufio.Reader) (*Format, error) {
+	// Peek returns what it could read when the file is shorter than sniffSize
+	head, err := input.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+	for i := range r.formats {
+		if r.formats[i].Sniff != nil && r.formats[i].Sniff(head) {
+			return &r.formats[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w of %s (expected one of %s)", ErrUnknownFormat, file.Source, strings.Join(r.Names(), ", "))
+}
@@ -0,0 +1,60 @@
+package file
+
+import (
+	"context"
+	"io"
+	"iter"
+	"strings"
+	"testing"
+
+	"transactions-summary/internal/entities"
+)
+
+// namedReader reads nothing and records that its format was chosen.
+type namedReader struct {
+	name   string
+	chosen *string
+}
+
+func (r namedReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	*r.chosen = r.name
+	return func(yield func(entities.Transaction, error) bool) {}, &entities.ValidationReport{Source: file.Source}
+}
+
+func TestRegistryDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    entities.FileInfo
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "declared format wins", file: entities.FileInfo{Source: "upload.csv", Format: "TXT"}, content: "a,b\n", want: "txt"},
+		{name: "extension", file: entities.FileInfo{Source: "s3://bucket/UPLOAD.CSV"}, want: "csv"},
+		{name: "content type with parameters", file: entities.FileInfo{Source: "upload", ContentType: "text/plain; charset=utf-8"}, want: "txt"},
+		{name: "sniffed content", file: entities.FileInfo{Source: "stdin"}, content: "Date,Amount,AccountId\n7/15,10,1\n", want: "csv"},
+		{name: "unknown declared format", file: entities.FileInfo{Source: "upload.csv", Format: "xls"}, wantErr: true},
+		{name: "undetectable content", file: entities.FileInfo{Source: "stdin"}, content: "\x00\x01binary", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chosen string
+			registry := NewRegistry(
+				Format{Name: "csv", Extensions: []string{".csv"}, ContentTypes: []string{"text/csv"}, Sniff: sniffCSV, Reader: namedReader{"csv", &chosen}},
+				Format{Name: "txt", Extensions: []string{".txt"}, ContentTypes: []string{"text/plain"}, Reader: namedReader{"txt", &chosen}},
+			)
+
+			transactions, _ := registry.ReadTransactions(context.Background(), tt.file, strings.NewReader(tt.content))
+			var err error
+			for _, err = range transactions {
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadTransactions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if chosen != tt.want {
+				t.Errorf("read as %q, want %q", chosen, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// Ensure InMemoryTransactionRepo implements interfaces.IngestionJobRepository
+var _ interfaces.IngestionJobRepository = &InMemoryTransactionRepo{}
+
+// CreateJob stores a new job and sets its ID.
+func (repo *InMemoryTransactionRepo) CreateJob(ctx context.Context, job *entities.IngestionJob) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	job.ID = int64(len(repo.jobs) + 1)
+	repo.jobs = append(repo.jobs, *job)
+	return nil
+}
+
+// UpdateJob stores the status, row counts, timings and error of a job.
+func (repo *InMemoryTransactionRepo) UpdateJob(ctx context.Context, job entities.IngestionJob) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if job.ID < 1 || job.ID > int64(len(repo.jobs)) {
+		return fmt.Errorf("ingestion job %d not found", job.ID)
+	}
+	repo.jobs[job.ID-1] = job
+	return nil
+}
+
+// FindJobs returns the jobs whose object key or source is key, newest first.
+func (repo *InMemoryTransactionRepo) FindJobs(ctx context.Context, key string) ([]entities.IngestionJob, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var jobs []entities.IngestionJob
+	for i := len(repo.jobs) - 1; i >= 0; i-- {
+		if repo.jobs[i].Key == key || repo.jobs[i].Source == key {
+			jobs = append(jobs, repo.jobs[i])
+		}
+	}
+	return jobs, nil
+}
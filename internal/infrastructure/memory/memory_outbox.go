@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// Ensure InMemoryTransactionRepo implements interfaces.OutboxRepository
+var _ interfaces.OutboxRepository = &InMemoryTransactionRepo{}
+
+// ClaimOutbox leases up to limit pending entries of uploadID, or of any upload
+// when it is empty, that are due at now.
+func (repo *InMemoryTransactionRepo) ClaimOutbox(ctx context.Context, uploadID string, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEntry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var claimed []entities.OutboxEntry
+	for i := range repo.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		entry := &repo.outbox[i]
+		if entry.Status != entities.OutboxPending || entry.NextAttemptAt.After(now) || repo.leases[entry.ID].After(now) {
+			continue
+		}
+		if uploadID != "" && entry.UploadID != uploadID {
+			continue
+		}
+		repo.leases[entry.ID] = now.Add(lease)
+		claimed = append(claimed, *entry)
+	}
+	return claimed, nil
+}
+
+// UpdateOutbox stores the delivery state of an entry and releases its lease.
+func (repo *InMemoryTransactionRepo) UpdateOutbox(ctx context.Context, entry entities.OutboxEntry) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if entry.ID < 1 || entry.ID > int64(len(repo.outbox)) {
+		return fmt.Errorf("outbox entry %d not found", entry.ID)
+	}
+	repo.outbox[entry.ID-1] = entry
+	delete(repo.leases, entry.ID)
+	return nil
+}
+
+// RequeueOutbox makes the matching entries due again with no attempts.
+func (repo *InMemoryTransactionRepo) RequeueOutbox(ctx context.Context, accountId string, uploadID string, now time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	count := 0
+	for i := range repo.outbox {
+		entry := &repo.outbox[i]
+		if (accountId != "" && entry.AccountID != accountId) || (uploadID != "" && entry.UploadID != uploadID) {
+			continue
+		}
+		entry.Status = entities.OutboxPending
+		entry.Attempts = 0
+		entry.NextAttemptAt = now
+		entry.LastError = ""
+		delete(repo.leases, entry.ID)
+		count++
+	}
+	return count, nil
+}
+
+// ListOutbox returns the entries of an upload ordered by account ID.
+func (repo *InMemoryTransactionRepo) ListOutbox(ctx context.Context, uploadID string) ([]entities.OutboxEntry, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var entries []entities.OutboxEntry
+	for _, entry := range repo.outbox {
+		if entry.UploadID == uploadID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AccountID < entries[j].AccountID
+	})
+	return entries, nil
+}
@@ -0,0 +1,375 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// InMemoryTransactionRepo implements the TransactionRepository interface in memory.
+// It is safe for concurrent use and is meant for tests and dry runs.
+type InMemoryTransactionRepo struct {
+	mu           sync.RWMutex
+	accounts     map[string]entities.Account
+	transactions map[string]entities.Transaction
+	outbox       []entities.OutboxEntry
+	leases       map[int64]time.Time // Lease expiry of claimed outbox entries
+	jobs         []entities.IngestionJob
+}
+
+// Ensure InMemoryTransactionRepo implements interfaces.TransactionRepository
+var _ interfaces.TransactionRepository = &InMemoryTransactionRepo{}
+
+// NewInMemoryTransactionRepo creates a new, empty InMemoryTransactionRepo instance.
+func NewInMemoryTransactionRepo() *InMemoryTransactionRepo {
+	return &InMemoryTransactionRepo{
+		accounts:     make(map[string]entities.Account),
+		transactions: make(map[string]entities.Transaction),
+		leases:       make(map[int64]time.Time),
+	}
+}
+
+// SeedAccounts adds the given accounts, replacing any with the same ID.
+// Accounts without a currency use entities.DefaultCurrency, and the balances
+// are expressed in the account currency.
+func (repo *InMemoryTransactionRepo) SeedAccounts(accounts []entities.Account) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, account := range accounts {
+		if account.Currency == "" {
+			account.Currency = entities.DefaultCurrency
+		}
+		account.DebitBalance.Currency = account.Currency
+		account.CreditBalance.Currency = account.Currency
+		repo.accounts[account.ID] = account
+	}
+}
+
+// LoadAccountsFixture seeds the repository with the accounts stored in a JSON
+// or YAML file, chosen by the .yaml or .yml extension. The file holds a list
+// of accounts using the entities.Account JSON field names in both formats.
+func (repo *InMemoryTransactionRepo) LoadAccountsFixture(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read accounts fixture: %w", err)
+	}
+
+	// YAML is converted to JSON, so both formats decode through the same
+	// field names and money parsing
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return fmt.Errorf("could not parse accounts fixture: %w", err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return fmt.Errorf("could not parse accounts fixture: %w", err)
+		}
+	}
+
+	var accounts []entities.Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return fmt.Errorf("could not parse accounts fixture: %w", err)
+	}
+
+	repo.SeedAccounts(accounts)
+	return nil
+}
+
+// SaveTransaction stores a new transaction.
+func (repo *InMemoryTransactionRepo) SaveTransaction(ctx context.Context, transaction entities.Transaction) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.transactions[transaction.ID]; exists {
+		return fmt.Errorf("could not save transaction: duplicate id %s", transaction.ID)
+	}
+	if _, exists := repo.accounts[transaction.AccountID]; !exists {
+		return fmt.Errorf("could not save transaction: unknown account %s", transaction.AccountID)
+	}
+	repo.transactions[transaction.ID] = transaction
+	return nil
+}
+
+// BeginUpload starts storing the transactions of an upload. The writer keeps
+// the chunks aside and applies them all at once when it commits.
+func (repo *InMemoryTransactionRepo) BeginUpload(ctx context.Context) (interfaces.UploadWriter, error) {
+	return &memoryUploadWriter{repo: repo, saved: make(map[string]entities.Transaction)}, nil
+}
+
+// memoryUploadWriter implements the UploadWriter interface for InMemoryTransactionRepo.
+type memoryUploadWriter struct {
+	repo         *InMemoryTransactionRepo
+	transactions []entities.Transaction
+	saved        map[string]entities.Transaction
+	done         bool
+}
+
+// GetExistingTransactions returns the transactions of ids that are already
+// stored or saved by the writer.
+func (w *memoryUploadWriter) GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error) {
+	w.repo.mu.RLock()
+	defer w.repo.mu.RUnlock()
+
+	existing := make(map[string]entities.Transaction)
+	for _, id := range ids {
+		if transaction, exists := w.saved[id]; exists {
+			existing[id] = transaction
+		} else if transaction, exists := w.repo.transactions[id]; exists {
+			existing[id] = transaction
+		}
+	}
+	return existing, nil
+}
+
+// SaveTransactions keeps a chunk of transactions until the writer commits. A
+// transaction of an unknown account fails the chunk, like the foreign key of
+// the database.
+func (w *memoryUploadWriter) SaveTransactions(ctx context.Context, transactions []entities.Transaction) error {
+	if w.done {
+		return fmt.Errorf("could not save transactions: upload already finished")
+	}
+
+	w.repo.mu.RLock()
+	defer w.repo.mu.RUnlock()
+	chunk := make(map[string]bool, len(transactions))
+	for _, transaction := range transactions {
+		_, saved := w.saved[transaction.ID]
+		_, stored := w.repo.transactions[transaction.ID]
+		if saved || stored || chunk[transaction.ID] {
+			return fmt.Errorf("could not save transactions: duplicate id %s", transaction.ID)
+		}
+		chunk[transaction.ID] = true
+		if _, exists := w.repo.accounts[transaction.AccountID]; !exists {
+			return fmt.Errorf("could not save transactions: unknown account %s", transaction.AccountID)
+		}
+	}
+	for _, transaction := range transactions {
+		w.saved[transaction.ID] = transaction
+		w.transactions = append(w.transactions, transaction)
+	}
+	return nil
+}
+
+// Commit stores the transactions, updates the account balances and enqueues
+// the outbox entries, or changes nothing if any ID is already taken.
+func (w *memoryUploadWriter) Commit(ctx context.Context, outbox []entities.OutboxEntry) error {
+	if w.done {
+		return fmt.Errorf("could not commit transactions: upload already finished")
+	}
+	w.done = true
+
+	repo := w.repo
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, transaction := range w.transactions {
+		if _, exists := repo.transactions[transaction.ID]; exists {
+			return fmt.Errorf("could not save transactions: duplicate id %s", transaction.ID)
+		}
+	}
+
+	for _, transaction := range w.transactions {
+		repo.transactions[transaction.ID] = transaction
+		account := repo.accounts[transaction.AccountID]
+		if account.ApplyTransaction(transaction) {
+			repo.accounts[transaction.AccountID] = account
+		} else if transaction.Amount.Currency != account.Currency {
+			log.Printf("Account %s balances are kept in %s: transaction %s in %s is left out of them", account.ID, account.Currency, transaction.ID, transaction.Amount.Currency)
+		}
+	}
+	for _, entry := range outbox {
+		entry.ID = int64(len(repo.outbox) + 1)
+		repo.outbox = append(repo.outbox, entry)
+	}
+	return nil
+}
+
+// Rollback discards the saved transactions. It is a no-op after Commit.
+func (w *memoryUploadWriter) Rollback() error {
+	if !w.done {
+		w.done = true
+		w.transactions = nil
+	}
+	return nil
+}
+
+// GetExistingTransactions returns the transactions of ids that are already stored.
+func (repo *InMemoryTransactionRepo) GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	existing := make(map[string]entities.Transaction)
+	for _, id := range ids {
+		if transaction, exists := repo.transactions[id]; exists {
+			existing[id] = transaction
+		}
+	}
+	return existing, nil
+}
+
+// GetExistingAccountIDs returns the subset of accountIds that are registered.
+func (repo *InMemoryTransactionRepo) GetExistingAccountIDs(ctx context.Context, accountIds []string) (map[string]bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	existing := make(map[string]bool)
+	for _, id := range accountIds {
+		if _, exists := repo.accounts[id]; exists {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+// GetTransaction retrieves a transaction by ID.
+func (repo *InMemoryTransactionRepo) GetTransaction(ctx context.Context, transactionID string) (*entities.Transaction, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	transaction, exists := repo.transactions[transactionID]
+	if !exists {
+		return nil, fmt.Errorf("transaction with id %s not found", transactionID)
+	}
+	return &transaction, nil
+}
+
+// ListTransactionsByAccount retrieves the transactions of an account between
+// from and to (inclusive), ordered by date. A zero from or to leaves that end open.
+func (repo *InMemoryTransactionRepo) ListTransactionsByAccount(ctx context.Context, accountId string, from, to time.Time) ([]entities.Transaction, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var transactions []entities.Transaction
+	for _, transaction := range repo.transactions {
+		if transaction.AccountID != accountId {
+			continue
+		}
+		if !from.IsZero() && transaction.TransactionDate.Before(from) {
+			continue
+		}
+		if !to.IsZero() && transaction.TransactionDate.After(to) {
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if !transactions[i].TransactionDate.Equal(transactions[j].TransactionDate) {
+			return transactions[i].TransactionDate.Before(transactions[j].TransactionDate)
+		}
+		return transactions[i].ID < transactions[j].ID
+	})
+	return transactions, nil
+}
+
+// ListTransactionsByUpload retrieves the transactions an upload stored for an
+// account, ordered by date.
+func (repo *InMemoryTransactionRepo) ListTransactionsByUpload(ctx context.Context, accountId string, uploadID string) ([]entities.Transaction, error) {
+	transactions, err := repo.ListTransactionsByAccount(ctx, accountId, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var uploaded []entities.Transaction
+	for _, transaction := range transactions {
+		if transaction.UploadID == uploadID {
+			uploaded = append(uploaded, transaction)
+		}
+	}
+	return uploaded, nil
+}
+
+// GetAccount retrieves an account by ID.
+func (repo *InMemoryTransactionRepo) GetAccount(ctx context.Context, id string) (*entities.Account, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	account, exists := repo.accounts[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", entities.ErrAccountNotFound, id)
+	}
+	return &account, nil
+}
+
+// GetAccounts retrieves all accounts, ordered by ID.
+func (repo *InMemoryTransactionRepo) GetAccounts(ctx context.Context) ([]entities.Account, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	accounts := make([]entities.Account, 0, len(repo.accounts))
+	for _, account := range repo.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts, nil
+}
+
+// GetTransactionTotals sums the stored debits and credits of an account in one currency.
+func (repo *InMemoryTransactionRepo) GetTransactionTotals(ctx context.Context, accountId string, currency string) (entities.Money, entities.Money, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	debit, credit := repo.transactionTotals(accountId, currency)
+	return debit, credit, nil
+}
+
+// transactionTotals sums the debits and credits of an account in one
+// currency. The caller holds the lock.
+func (repo *InMemoryTransactionRepo) transactionTotals(accountId string, currency string) (entities.Money, entities.Money) {
+	totals := entities.Account{
+		DebitBalance:  entities.NewMoney(0, currency),
+		CreditBalance: entities.NewMoney(0, currency),
+		Currency:      currency,
+	}
+	for _, transaction := range repo.transactions {
+		if transaction.AccountID == accountId {
+			totals.ApplyTransaction(transaction)
+		}
+	}
+	return totals.DebitBalance, totals.CreditBalance
+}
+
+// RecomputeBalances overwrites the balances of an account with the totals of
+// its transactions in the account currency, holding the lock throughout.
+func (repo *InMemoryTransactionRepo) RecomputeBalances(ctx context.Context, accountId string) (*entities.Account, *entities.Account, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, exists := repo.accounts[accountId]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", entities.ErrAccountNotFound, accountId)
+	}
+	recomputed := stored
+	recomputed.DebitBalance, recomputed.CreditBalance = repo.transactionTotals(accountId, stored.Currency)
+	repo.accounts[accountId] = recomputed
+	return &stored, &recomputed, nil
+}
+
+// UpdateAccount updates the balances of an existing account.
+func (repo *InMemoryTransactionRepo) UpdateAccount(ctx context.Context, account *entities.Account) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, exists := repo.accounts[account.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entities.ErrAccountNotFound, account.ID)
+	}
+	stored.DebitBalance = account.DebitBalance
+	stored.CreditBalance = account.CreditBalance
+	repo.accounts[account.ID] = stored
+	return nil
+}
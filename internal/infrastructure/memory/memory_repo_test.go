@@ -0,0 +1,304 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
+
+func TestLoadAccountsFixture(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "JSON", path: "../../../testData/accounts.json"},
+		{name: "YAML", path: "../../../testData/accounts.yaml"},
+		{name: "missing file", path: "../../../testData/missing.json", wantErr: true},
+		{name: "not a list of accounts", path: "../../../testData/transactions.csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewInMemoryTransactionRepo()
+			err := repo.LoadAccountsFixture(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadAccountsFixture() returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i := 1; i <= 4; i++ {
+				id := fmt.Sprint(i)
+				account, err := repo.GetAccount(context.Background(), id)
+				if err != nil {
+					t.Fatalf("account %s was not seeded: %v", id, err)
+				}
+				if want := fmt.Sprintf("account%d@example.com", i); account.Email != want {
+					t.Errorf("account %s email = %q, want %q", id, account.Email, want)
+				}
+				if want := map[string]string{"2": "es-MX"}[id]; account.Locale != want {
+					t.Errorf("account %s locale = %q, want %q", id, account.Locale, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedAccounts(t *testing.T) {
+	repo := NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{
+		{ID: "1", Email: "old@example.com"},
+		{ID: "2", Email: "two@example.com"},
+	})
+	// Seeding again replaces the accounts with the same ID
+	repo.SeedAccounts([]entities.Account{{ID: "1", Email: "new@example.com"}})
+
+	tests := []struct {
+		id        string
+		wantEmail string
+		wantErr   bool
+	}{
+		{id: "1", wantEmail: "new@example.com"},
+		{id: "2", wantEmail: "two@example.com"},
+		{id: "3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		account, err := repo.GetAccount(context.Background(), tt.id)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("GetAccount(%q) returned no error", tt.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GetAccount(%q) returned error: %v", tt.id, err)
+			continue
+		}
+		if account.Email != tt.wantEmail {
+			t.Errorf("GetAccount(%q) email = %q, want %q", tt.id, account.Email, tt.wantEmail)
+		}
+	}
+}
+
+func TestSaveAndGetTransaction(t *testing.T) {
+	repo := NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{{ID: "1", Email: "one@example.com"}})
+	transaction := entities.Transaction{
+		ID:              "t1",
+		AccountID:       "1",
+		Amount:          entities.NewMoney(6050, "USD"),
+		TransactionDate: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC),
+		Type:            "credit",
+	}
+	if err := repo.SaveTransaction(context.Background(), transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		save    *entities.Transaction
+		get     string
+		wantErr bool
+	}{
+		{name: "stored transaction", get: "t1"},
+		{name: "unknown transaction", get: "t2", wantErr: true},
+		{name: "duplicate ID", save: &transaction, wantErr: true},
+		{name: "unknown account", save: &entities.Transaction{ID: "t3", AccountID: "9"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.save != nil {
+				if err := repo.SaveTransaction(context.Background(), *tt.save); (err != nil) != tt.wantErr {
+					t.Errorf("SaveTransaction() error = %v, want error %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			got, err := repo.GetTransaction(context.Background(), tt.get)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetTransaction(%q) returned no error", tt.get)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetTransaction(%q) returned error: %v", tt.get, err)
+			}
+			if !reflect.DeepEqual(*got, transaction) {
+				t.Errorf("GetTransaction(%q) = %+v, want %+v", tt.get, *got, transaction)
+			}
+		})
+	}
+}
+
+func TestUpdateAccount(t *testing.T) {
+	repo := NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{{ID: "1", Email: "one@example.com"}})
+
+	// Only the balances are updated
+	update := &entities.Account{ID: "1", DebitBalance: entities.NewMoney(-1030, "USD"), CreditBalance: entities.NewMoney(6050, "USD"), Email: "changed@example.com"}
+	if err := repo.UpdateAccount(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+	account, err := repo.GetAccount(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := entities.Account{ID: "1", DebitBalance: entities.NewMoney(-1030, "USD"), CreditBalance: entities.NewMoney(6050, "USD"), Email: "one@example.com", Currency: "USD"}
+	if *account != want {
+		t.Errorf("account = %+v, want %+v", *account, want)
+	}
+
+	if err := repo.UpdateAccount(context.Background(), &entities.Account{ID: "2"}); err == nil {
+		t.Error("UpdateAccount() of an unknown account returned no error")
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	repo := NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{{ID: "1", Email: "one@example.com"}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.SaveTransaction(context.Background(), entities.Transaction{ID: fmt.Sprint(i), AccountID: "1"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 50; i++ {
+		if _, err := repo.GetTransaction(context.Background(), fmt.Sprint(i)); err != nil {
+			t.Errorf("transaction %d was not stored: %v", i, err)
+		}
+	}
+}
+
+func TestUploadWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   [][]entities.Transaction
+		rollback bool
+		wantErr  bool
+		want     int // Transactions stored afterwards, including t1
+	}{
+		{
+			name:   "chunks are stored on commit",
+			chunks: [][]entities.Transaction{{{ID: "t2", AccountID: "1"}}, {{ID: "t3", AccountID: "2"}}},
+			want:   3,
+		},
+		{
+			name:     "rollback stores nothing",
+			chunks:   [][]entities.Transaction{{{ID: "t2", AccountID: "1"}, {ID: "t3", AccountID: "2"}}},
+			rollback: true,
+			want:     1,
+		},
+		{
+			name:    "stored ID",
+			chunks:  [][]entities.Transaction{{{ID: "t2", AccountID: "1"}, {ID: "t1", AccountID: "1"}}},
+			wantErr: true,
+			want:    1,
+		},
+		{
+			name:    "ID repeated in the chunk",
+			chunks:  [][]entities.Transaction{{{ID: "t2", AccountID: "1"}, {ID: "t2", AccountID: "1"}}},
+			wantErr: true,
+			want:    1,
+		},
+		{
+			name:    "ID saved by an earlier chunk",
+			chunks:  [][]entities.Transaction{{{ID: "t2", AccountID: "1"}}, {{ID: "t2", AccountID: "1"}}},
+			wantErr: true,
+			want:    1,
+		},
+		{
+			name:    "unknown account",
+			chunks:  [][]entities.Transaction{{{ID: "t2", AccountID: "1"}, {ID: "t3", AccountID: "9"}}},
+			wantErr: true,
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewInMemoryTransactionRepo()
+			repo.SeedAccounts([]entities.Account{{ID: "1"}, {ID: "2"}})
+			if err := repo.SaveTransaction(ctx, entities.Transaction{ID: "t1", AccountID: "1"}); err != nil {
+				t.Fatal(err)
+			}
+
+			writer, err := repo.BeginUpload(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{"t1"}
+			for _, chunk := range tt.chunks {
+				if err = writer.SaveTransactions(ctx, chunk); err != nil {
+					break
+				}
+				for _, transaction := range chunk {
+					ids = append(ids, transaction.ID)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SaveTransactions() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			// The writer sees its own chunks before they are committed
+			existing, err := writer.GetExistingTransactions(ctx, ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(existing) != len(ids) {
+				t.Errorf("writer sees %d of the transactions %v", len(existing), ids)
+			}
+
+			if tt.wantErr || tt.rollback {
+				err = writer.Rollback()
+			} else {
+				err = writer.Commit(ctx, nil)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A failed or rolled back upload stores nothing
+			stored, err := repo.GetExistingTransactions(ctx, []string{"t1", "t2", "t3"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != tt.want {
+				t.Errorf("%d transactions stored, want %d", len(stored), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetExistingAccountIDs(t *testing.T) {
+	repo := NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{{ID: "1"}, {ID: "2"}})
+
+	got, err := repo.GetExistingAccountIDs(context.Background(), []string{"1", "3", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"1": true, "2": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetExistingAccountIDs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// CSVStatement implements the StatementGenerator interface by listing the
+// transactions of the account as CSV, oldest first.
+type CSVStatement struct{}
+
+// Ensure CSVStatement implements interfaces.StatementGenerator
+var _ interfaces.StatementGenerator = &CSVStatement{}
+
+// NewCSVStatement creates a new CSVStatement.
+func NewCSVStatement() *CSVStatement {
+	return &CSVStatement{}
+}
+
+// GenerateStatement writes one row per transaction of the summary. The columns
+// use names the CSV reader accepts, so the file can be inspected with the same tools.
+func (g *CSVStatement) GenerateStatement(summary *entities.SummaryResult) (*entities.Attachment, error) {
+	sorted := sortedTransactions(summary.Transactions)
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write([]string{"Id", "Date", "AccountId", "Transaction", "Currency", "Type", "Description", "Merchant"}); err != nil {
+		return nil, fmt.Errorf("could not write CSV statement: %w", err)
+	}
+	for _, transaction := range sorted {
+		record := []string{
+			transaction.ID,
+			transaction.TransactionDate.Format("2006-01-02"),
+			transaction.AccountID,
+			transaction.Amount.String(),
+			transaction.Amount.Currency,
+			transaction.Type,
+			transaction.Description,
+			transaction.Merchant,
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("could not write CSV statement: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("could not write CSV statement: %w", err)
+	}
+
+	return &entities.Attachment{
+		Filename:    statementFilename(summary, "csv"),
+		ContentType: "text/csv",
+		Data:        buffer.Bytes(),
+	}, nil
+}
+
+// sortedTransactions returns a copy of the transactions ordered by date, then ID.
+func sortedTransactions(transactions []entities.Transaction) []entities.Transaction {
+	sorted := append([]entities.Transaction(nil), transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].TransactionDate.Equal(sorted[j].TransactionDate) {
+			return sorted[i].TransactionDate.Before(sorted[j].TransactionDate)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// statementFilename names the attachment after the account, e.g. "statement-1.pdf".
+func statementFilename(summary *entities.SummaryResult, extension string) string {
+	if summary.AccountID == "" {
+		return "statement." + extension
+	}
+	return fmt.Sprintf("statement-%s.%s", summary.AccountID, extension)
+}
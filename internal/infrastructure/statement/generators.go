@@ -0,0 +1,27 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+
+	"transactions-summary/internal/interfaces"
+)
+
+// ParseGenerators returns the statement generators named in a comma-separated
+// list such as "csv,pdf". An empty list attaches no statements. The localizer
+// writes the PDF statement in the locale of each account.
+func ParseGenerators(value string, localizer interfaces.Localizer) ([]interfaces.StatementGenerator, error) {
+	var generators []interfaces.StatementGenerator
+	for _, name := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "csv":
+			generators = append(generators, NewCSVStatement())
+		case "pdf":
+			generators = append(generators, NewPDFStatement(localizer))
+		default:
+			return nil, fmt.Errorf("unknown statement format %q (expected csv or pdf)", name)
+		}
+	}
+	return generators, nil
+}
@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page layout in PDF points (1/72 inch) for an A4 page.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	pageMargin = 50
+)
+
+// pdfCell is a piece of text drawn at a horizontal offset from the left margin.
+type pdfCell struct {
+	x    float64
+	text string
+}
+
+// pdfLine is one line of text cells sharing a font and size.
+type pdfLine struct {
+	cells []pdfCell
+	bold  bool
+	size  float64
+}
+
+// pdfDocument lays out lines of text on as many pages as needed and writes
+// them as a PDF using the standard Helvetica fonts, so no font is embedded.
+type pdfDocument struct {
+	pages [][]string // Content stream operators of each page
+	y     float64    // Baseline of the next line on the current page
+}
+
+// addLine draws the cells on the next line, starting a new page when the
+// current one is full.
+func (d *pdfDocument) addLine(line pdfLine) {
+	leading := line.size * 1.5
+	if len(d.pages) == 0 || d.y-leading < pageMargin {
+		d.pages = append(d.pages, nil)
+		d.y = pageHeight - pageMargin
+	}
+	d.y -= leading
+
+	font := "/F1"
+	if line.bold {
+		font = "/F2"
+	}
+	page := len(d.pages) - 1
+	for _, cell := range line.cells {
+		d.pages[page] = append(d.pages[page], fmt.Sprintf("BT %s %.1f Tf %.1f %.1f Td (%s) Tj ET",
+			font, line.size, pageMargin+cell.x, d.y, escapePDFText(cell.text)))
+	}
+}
+
+// text adds a single line of text.
+func (d *pdfDocument) text(text string, size float64, bold bool) {
+	d.addLine(pdfLine{cells: []pdfCell{{text: text}}, size: size, bold: bold})
+}
+
+// space adds an empty line.
+func (d *pdfDocument) space() {
+	d.addLine(pdfLine{size: 6})
+}
+
+// bytes writes the document: catalog, page tree, fonts, then one page object
+// and one content stream per page, followed by the cross-reference table.
+func (d *pdfDocument) bytes() []byte {
+	if len(d.pages) == 0 {
+		d.pages = append(d.pages, nil)
+	}
+
+	var objects []string
+	pageCount := len(d.pages)
+	firstPage := 5 // Objects 1 to 4 are the catalog, the page tree and the fonts
+
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, operators := range d.pages {
+		content := strings.Join(operators, "\n")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, firstPage+i*2+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buffer.Len()
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buffer.Bytes()
+}
+
+// escapePDFText escapes a string for a PDF literal. Characters outside Latin-1
+// and the euro sign cannot be shown with WinAnsiEncoding and are replaced with "?".
+func escapePDFText(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			sb.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		case r == '€':
+			sb.WriteString("\\200") // The euro sign in WinAnsiEncoding
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"fmt"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// PDFStatement implements the StatementGenerator interface with a printable
+// statement: totals and monthly breakdown per currency, then every transaction.
+// Labels, month names and amounts follow the locale of the account.
+type PDFStatement struct {
+	Localizer interfaces.Localizer
+	now       func() time.Time // Clock for the generation date
+}
+
+// Ensure PDFStatement implements interfaces.StatementGenerator
+var _ interfaces.StatementGenerator = &PDFStatement{}
+
+// NewPDFStatement creates a new PDFStatement that writes with localizer.
+func NewPDFStatement(localizer interfaces.Localizer) *PDFStatement {
+	return &PDFStatement{Localizer: localizer, now: time.Now}
+}
+
+// Column offsets of the tables, in points from the left margin.
+var (
+	monthColumns       = []float64{0, 130, 215, 310, 405}
+	transactionColumns = []float64{0, 75, 345, 420}
+)
+
+// GenerateStatement builds the PDF statement of a summary.
+func (g *PDFStatement) GenerateStatement(summary *entities.SummaryResult) (*entities.Attachment, error) {
+	t := func(key string) string { return g.Localizer.Translate(summary.Locale, key) }
+	money := func(m entities.Money) string { return g.Localizer.FormatMoney(summary.Locale, m) }
+
+	doc := &pdfDocument{}
+	doc.text(t("statement_title"), 18, true)
+	if summary.AccountID != "" {
+		doc.text(t("account")+": "+summary.AccountID, 10, false)
+	}
+	doc.text(t("generated")+": "+g.now().Format("2006-01-02"), 10, false)
+
+	for _, currency := range summary.Currencies {
+		doc.space()
+		doc.text(currency.Currency, 14, true)
+		doc.text(t("total_credit")+": "+money(currency.TotalCredit), 10, false)
+		doc.text(t("total_debit")+": "+money(currency.TotalDebit), 10, false)
+		doc.space()
+		doc.addLine(row(monthColumns, true, t("month"), t("transactions"), t("avg_credit"), t("avg_debit"), t("balance")))
+		for _, month := range currency.MonthlySummaries {
+			doc.addLine(row(monthColumns, false,
+				fmt.Sprintf("%s %d", g.Localizer.MonthName(summary.Locale, month.MonthNumber), month.Year),
+				fmt.Sprint(month.NumTransactions),
+				money(month.AverageCredit),
+				money(month.AverageDebit),
+				money(month.ClosingBalance),
+			))
+		}
+	}
+
+	if len(summary.Transactions) > 0 {
+		doc.space()
+		doc.text(t("transactions"), 14, true)
+		doc.addLine(row(transactionColumns, true, t("date"), t("description"), t("type"), t("amount")))
+		for _, transaction := range sortedTransactions(summary.Transactions) {
+			description := transaction.Description
+			if transaction.Merchant != "" {
+				description = transaction.Merchant + " " + description
+			}
+			doc.addLine(row(transactionColumns, false,
+				transaction.TransactionDate.Format("2006-01-02"),
+				truncate(description, 45),
+				t(transaction.Type),
+				money(transaction.Amount),
+			))
+		}
+	}
+
+	return &entities.Attachment{
+		Filename:    statementFilename(summary, "pdf"),
+		ContentType: "application/pdf",
+		Data:        doc.bytes(),
+	}, nil
+}
+
+// row builds a table line with one cell per column.
+func row(columns []float64, bold bool, values ...string) pdfLine {
+	line := pdfLine{size: 10, bold: bold}
+	for i, value := range values {
+		line.cells = append(line.cells, pdfCell{x: columns[i], text: value})
+	}
+	return line
+}
+
+// truncate shortens text to at most n characters so it fits its column.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-3]) + "..."
+}
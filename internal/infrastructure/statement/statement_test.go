@@ -0,0 +1,112 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/emailtemplate"
+)
+
+// testSummary returns a summary in locale covering two transactions, listed
+// newest first.
+func testSummary(locale string) *entities.SummaryResult {
+	return &entities.SummaryResult{
+		AccountID: "7",
+		Locale:    locale,
+		Currencies: []entities.CurrencySummary{{
+			Currency:    "EUR",
+			TotalCredit: entities.NewMoney(123456, "EUR"),
+			TotalDebit:  entities.NewMoney(-1030, "EUR"),
+			MonthlySummaries: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 2, AverageCredit: entities.NewMoney(123456, "EUR"), AverageDebit: entities.NewMoney(-1030, "EUR"), ClosingBalance: entities.NewMoney(122426, "EUR")},
+			},
+		}},
+		Transactions: []entities.Transaction{
+			{ID: "b", AccountID: "7", Amount: entities.NewMoney(-1030, "EUR"), TransactionDate: time.Date(2024, 7, 28, 0, 0, 0, 0, time.UTC), Type: "debit", Description: "Café, \"to go\"", Merchant: "Corner"},
+			{ID: "a", AccountID: "7", Amount: entities.NewMoney(123456, "EUR"), TransactionDate: time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), Type: "credit"},
+		},
+	}
+}
+
+func TestParseGenerators(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "csv", want: 1},
+		{value: " PDF , csv ", want: 2},
+		{value: "csv,docx", wantErr: true},
+	}
+	for _, tt := range tests {
+		generators, err := ParseGenerators(tt.value, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseGenerators(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if len(generators) != tt.want {
+			t.Errorf("ParseGenerators(%q) returned %d generators, want %d", tt.value, len(generators), tt.want)
+		}
+	}
+}
+
+func TestCSVStatement(t *testing.T) {
+	attachment, err := NewCSVStatement().GenerateStatement(testSummary("en-US"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Id,Date,AccountId,Transaction,Currency,Type,Description,Merchant\n" +
+		"a,2024-07-15,7,1234.56,EUR,credit,,\n" +
+		"b,2024-07-28,7,-10.30,EUR,debit,\"Café, \"\"to go\"\"\",Corner\n"
+	if got := string(attachment.Data); got != want {
+		t.Errorf("CSV statement =\n%s\nwant\n%s", got, want)
+	}
+	if attachment.Filename != "statement-7.csv" || attachment.ContentType != "text/csv" {
+		t.Errorf("attachment = %s (%s), want statement-7.csv (text/csv)", attachment.Filename, attachment.ContentType)
+	}
+}
+
+func TestPDFStatementLocale(t *testing.T) {
+	renderer, err := emailtemplate.NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		locale string
+		want   []string // Text drawn on the page, escaped for PDF
+	}{
+		{
+			locale: "en-US",
+			want:   []string{"(Account Statement)", "(Account: 7)", "(Generated: 2024-09-01)", "(July 2024)", "(Avg Credit)", "(Description)", "(Credit)", "(\\2001,234.56 EUR)", "(-\\20010.30 EUR)", "(Corner Caf\\351, \"to go\")"},
+		},
+		{
+			locale: "es-MX",
+			want:   []string{"(Estado de cuenta)", "(Cuenta: 7)", "(Generado: 2024-09-01)", "(julio 2024)", "(Abono promedio)", "(Descripci\\363n)", "(Abono)", "(Cargo)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			generator := NewPDFStatement(renderer)
+			generator.now = func() time.Time { return time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC) }
+			attachment, err := generator.GenerateStatement(testSummary(tt.locale))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := string(attachment.Data)
+			if !strings.HasPrefix(data, "%PDF-1.4\n") || !strings.HasSuffix(data, "%%EOF\n") {
+				t.Error("statement is not a complete PDF document")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(data, want) {
+					t.Errorf("statement does not contain %s", want)
+				}
+			}
+		})
+	}
+}
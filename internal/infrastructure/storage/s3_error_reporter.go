@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/file"
+	"transactions-summary/internal/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3ErrorReporter implements the ErrorReporter interface by writing the reports
+// next to the uploaded object, as <key>.errors.csv and <key>.quarantine.csv.
+type S3ErrorReporter struct {
+	Client *s3.Client
+}
+
+// Ensure S3ErrorReporter implements interfaces.ErrorReporter
+var _ interfaces.ErrorReporter = &S3ErrorReporter{}
+
+// NewS3ErrorReporter creates a new S3ErrorReporter instance.
+func NewS3ErrorReporter(client *s3.Client) *S3ErrorReporter {
+	return &S3ErrorReporter{Client: client}
+}
+
+// Suffixes of the report objects written next to an upload.
+const (
+	errorReportSuffix = ".errors.csv"
+	quarantineSuffix  = ".quarantine.csv"
+)
+
+// IsReportKey reports whether an object key is a report written by S3ErrorReporter.
+// Such objects must not be processed as uploads.
+func IsReportKey(key string) bool {
+	return strings.HasSuffix(key, errorReportSuffix) || strings.HasSuffix(key, quarantineSuffix)
+}
+
+// ReportErrors uploads the error report next to the source object.
+func (r *S3ErrorReporter) ReportErrors(ctx context.Context, report *entities.ValidationReport) error {
+	var buffer bytes.Buffer
+	if err := file.WriteErrorReport(&buffer, report); err != nil {
+		return err
+	}
+	return r.put(ctx, report.Source, errorReportSuffix, &buffer)
+}
+
+// Quarantine uploads the invalid rows next to the source object.
+func (r *S3ErrorReporter) Quarantine(ctx context.Context, report *entities.ValidationReport) error {
+	var buffer bytes.Buffer
+	if err := file.WriteQuarantine(&buffer, report); err != nil {
+		return err
+	}
+	return r.put(ctx, report.Source, quarantineSuffix, &buffer)
+}
+
+func (r *S3ErrorReporter) put(ctx context.Context, source, suffix string, body *bytes.Buffer) error {
+	bucket, key, err := ParseS3URI(source)
+	if err != nil {
+		return err
+	}
+	key += suffix
+
+	_, err = r.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String("text/csv"),
+	})
+	if err != nil {
+		return fmt.Errorf("could not upload %s: %w", key, err)
+	}
+	log.Printf("Uploaded s3://%s/%s", bucket, key)
+	return nil
+}
+
+// ParseS3URI splits an "s3://bucket/key" source into bucket and key.
+func ParseS3URI(source string) (string, string, error) {
+	path, ok := strings.CutPrefix(source, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("not an S3 source: %s", source)
+	}
+	bucket, key, ok := strings.Cut(path, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 source: %s", source)
+	}
+	return bucket, key, nil
+}
@@ -1,6 +1,12 @@
 package interfaces
 
+import (
+	"context"
+
+	"transactions-summary/internal/entities"
+)
+
 // EmailSender defines the interface for sending emails.
 type EmailSender interface {
-	SendEmail(to string, subject string, body string) error
+	SendEmail(ctx context.Context, message entities.EmailMessage) error
 }
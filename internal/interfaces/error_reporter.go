@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"transactions-summary/internal/entities"
+)
+
+// ErrorReporter defines the interface for publishing the rows of a file that failed validation.
+type ErrorReporter interface {
+	// ReportErrors publishes the line, column and reason of every invalid value.
+	ReportErrors(ctx context.Context, report *entities.ValidationReport) error
+	// Quarantine stores the raw invalid rows so they can be fixed and uploaded again.
+	Quarantine(ctx context.Context, report *entities.ValidationReport) error
+}
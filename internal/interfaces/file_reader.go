@@ -1,12 +1,22 @@
 package interfaces
 
 import (
-	"encoding/csv"
+	"context"
+	"io"
+	"iter"
 
 	"transactions-summary/internal/entities"
 )
 
 // FileReader defines the interface for reading transactions from a file.
+// The file source (e.g. "s3://bucket/key") is used to derive stable
+// transaction IDs. Rows that fail validation are added to the report instead
+// of failing the whole read.
+//
+// The transactions are read one row at a time while the sequence is iterated,
+// so a file of any size is never held in memory. The report is complete once
+// the sequence ends. A non-nil error means the file cannot be read any further
+// and is always the last element of the sequence.
 type FileReader interface {
-	ReadTransactions(reader *csv.Reader) ([]entities.Transaction, error)
+	ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport)
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"transactions-summary/internal/entities"
+)
+
+// IngestionJobRepository defines the interface for the record of processed uploads.
+type IngestionJobRepository interface {
+	// CreateJob stores a new job and sets its ID.
+	CreateJob(ctx context.Context, job *entities.IngestionJob) error
+	// UpdateJob stores the status, row counts, timings and error of a job.
+	UpdateJob(ctx context.Context, job entities.IngestionJob) error
+	// FindJobs returns the jobs whose object key or source is key, newest first.
+	FindJobs(ctx context.Context, key string) ([]entities.IngestionJob, error)
+}
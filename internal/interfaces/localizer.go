@@ -0,0 +1,11 @@
+package interfaces
+
+import "transactions-summary/internal/entities"
+
+// Localizer defines the interface for translating labels and formatting
+// dates and amounts in the locale of an account, e.g. "es-MX".
+type Localizer interface {
+	Translate(locale, key string) string
+	MonthName(locale string, month int) string
+	FormatMoney(locale string, m entities.Money) string
+}
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
+
+// OutboxRepository defines the interface for the summary emails waiting to be
+// delivered. Entries are added by UploadWriter.Commit.
+type OutboxRepository interface {
+	// ClaimOutbox leases up to limit pending entries that are due at now, only
+	// those of uploadID unless it is empty. Other dispatchers skip leased
+	// entries until the lease expires, so an entry whose dispatcher crashed is
+	// picked up again later.
+	ClaimOutbox(ctx context.Context, uploadID string, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEntry, error)
+	// UpdateOutbox stores the status, attempts, next attempt, error and sent
+	// time of an entry and releases its lease.
+	UpdateOutbox(ctx context.Context, entry entities.OutboxEntry) error
+	// RequeueOutbox makes the entries of an account, an upload, or both due
+	// again with no attempts, whatever their status. An empty filter matches
+	// any value. It returns the number of entries requeued.
+	RequeueOutbox(ctx context.Context, accountId string, uploadID string, now time.Time) (int, error)
+	// ListOutbox returns the entries of an upload ordered by account ID.
+	ListOutbox(ctx context.Context, uploadID string) ([]entities.OutboxEntry, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "transactions-summary/internal/entities"
+
+// StatementGenerator defines the interface for building a statement file that
+// is attached to the summary email of an account. Statements list the
+// transactions of the summary, so they cover the same period as the email.
+type StatementGenerator interface {
+	GenerateStatement(summary *entities.SummaryResult) (*entities.Attachment, error)
+}
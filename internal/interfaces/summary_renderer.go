@@ -0,0 +1,9 @@
+package interfaces
+
+import "transactions-summary/internal/entities"
+
+// SummaryRenderer defines the interface for turning a summary into an email.
+// The returned message has its subject and bodies set but no recipient.
+type SummaryRenderer interface {
+	RenderSummary(summary *entities.SummaryResult) (*entities.EmailMessage, error)
+}
@@ -1,11 +1,50 @@
 package interfaces
 
-import "transactions-summary/internal/entities"
+import (
+	"context"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
 
 // TransactionRepository defines the interface for database operations.
 type TransactionRepository interface {
-	SaveTransaction(transaction entities.Transaction) error
-	GetAccount(accountId string) (*entities.Account, error)
-	UpdateAccount(account *entities.Account) error
-	GetTransaction(transactionID string) (*entities.Transaction, error)
+	SaveTransaction(ctx context.Context, transaction entities.Transaction) error
+	// BeginUpload starts storing the transactions of an upload. Nothing the
+	// writer saves is visible to others until it commits.
+	BeginUpload(ctx context.Context) (UploadWriter, error)
+	// GetExistingTransactions returns the transactions of ids that are already stored, by ID.
+	GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error)
+	// GetExistingAccountIDs returns the subset of accountIds that are registered.
+	GetExistingAccountIDs(ctx context.Context, accountIds []string) (map[string]bool, error)
+	GetAccount(ctx context.Context, accountId string) (*entities.Account, error)
+	GetAccounts(ctx context.Context) ([]entities.Account, error)
+	UpdateAccount(ctx context.Context, account *entities.Account) error
+	GetTransaction(ctx context.Context, transactionID string) (*entities.Transaction, error)
+	// ListTransactionsByAccount returns the transactions of an account between
+	// from and to (inclusive), ordered by date. A zero from or to leaves that end open.
+	ListTransactionsByAccount(ctx context.Context, accountId string, from, to time.Time) ([]entities.Transaction, error)
+	// ListTransactionsByUpload returns the transactions an upload stored for an account, ordered by date.
+	ListTransactionsByUpload(ctx context.Context, accountId string, uploadID string) ([]entities.Transaction, error)
+	// GetTransactionTotals sums the stored debits and credits of an account in one currency.
+	GetTransactionTotals(ctx context.Context, accountId string, currency string) (debit entities.Money, credit entities.Money, err error)
+	// RecomputeBalances atomically overwrites the balances of an account with
+	// the totals of its transactions in the account currency. It returns the
+	// account before and after the update.
+	RecomputeBalances(ctx context.Context, accountId string) (stored *entities.Account, recomputed *entities.Account, err error)
+}
+
+// UploadWriter stores the transactions of one upload chunk by chunk, together
+// with the summary emails they owe. Either everything is stored or nothing is.
+type UploadWriter interface {
+	// GetExistingTransactions returns the transactions of ids that are
+	// already stored, including the ones saved by this writer, by ID.
+	GetExistingTransactions(ctx context.Context, ids []string) (map[string]entities.Transaction, error)
+	// SaveTransactions saves a chunk of transactions and adds them to the
+	// owning account balances. Every account must be registered.
+	SaveTransactions(ctx context.Context, transactions []entities.Transaction) error
+	// Commit enqueues the outbox entries and stores everything for good.
+	Commit(ctx context.Context, outbox []entities.OutboxEntry) error
+	// Rollback discards everything saved. It is a no-op after Commit.
+	Rollback() error
 }
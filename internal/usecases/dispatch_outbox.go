@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// Defaults of DispatchOutbox.
+const (
+	defaultOutboxMaxAttempts = 5
+	defaultOutboxBaseDelay   = time.Minute
+	defaultOutboxMaxDelay    = time.Hour
+	defaultOutboxLease       = 10 * time.Minute
+	defaultOutboxBatchSize   = 50
+)
+
+// DispatchOutbox is a use case that delivers the pending summary emails of the
+// outbox. Failed deliveries are retried with exponential backoff until
+// MaxAttempts is reached, then the entry is marked failed.
+type DispatchOutbox struct {
+	OutboxRepo       interfaces.OutboxRepository
+	TransactionRepo  interfaces.TransactionRepository
+	SendSummaryEmail *SendSummaryEmail
+	MaxAttempts      int           // Attempts before an entry is marked failed
+	BaseDelay        time.Duration // Delay after the first failure, doubled after each one
+	MaxDelay         time.Duration // Upper bound of the delay between attempts
+	Lease            time.Duration // Time a dispatcher may hold an entry before others retry it
+	BatchSize        int           // Entries claimed at once
+}
+
+// NewDispatchOutbox creates a new DispatchOutbox use case with the default
+// retry settings.
+func NewDispatchOutbox(outboxRepo interfaces.OutboxRepository, transactionRepo interfaces.TransactionRepository, sendSummaryEmail *SendSummaryEmail) *DispatchOutbox {
+	return &DispatchOutbox{
+		OutboxRepo:       outboxRepo,
+		TransactionRepo:  transactionRepo,
+		SendSummaryEmail: sendSummaryEmail,
+		MaxAttempts:      defaultOutboxMaxAttempts,
+		BaseDelay:        defaultOutboxBaseDelay,
+		MaxDelay:         defaultOutboxMaxDelay,
+		Lease:            defaultOutboxLease,
+		BatchSize:        defaultOutboxBatchSize,
+	}
+}
+
+// Execute delivers every entry that is due, batch by batch, and returns the
+// outcome of each one. Entries that fail again are scheduled for later, so a
+// run ends once nothing else is due or ctx is canceled.
+func (uc *DispatchOutbox) Execute(ctx context.Context) (*SummaryReport, error) {
+	return uc.ExecuteUpload(ctx, "")
+}
+
+// ExecuteUpload is like Execute but only delivers the entries of one upload,
+// so processing a file does not send the retries of other uploads. An empty
+// uploadID delivers every due entry.
+func (uc *DispatchOutbox) ExecuteUpload(ctx context.Context, uploadID string) (*SummaryReport, error) {
+	report := &SummaryReport{}
+	for {
+		entries, err := uc.OutboxRepo.ClaimOutbox(ctx, uploadID, time.Now(), uc.Lease, uc.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(entries) == 0 {
+			return report, nil
+		}
+
+		batch, sendErr := uc.deliver(ctx, entries)
+		report.Outcomes = append(report.Outcomes, batch.Outcomes...)
+		if sendErr != nil {
+			return report, sendErr
+		}
+	}
+}
+
+// deliver sends the summaries of claimed entries and records the result of each one.
+func (uc *DispatchOutbox) deliver(ctx context.Context, entries []entities.OutboxEntry) (*SummaryReport, error) {
+	jobs := make([]SummaryJob, len(entries))
+	for i, entry := range entries {
+		transactions, err := uc.TransactionRepo.ListTransactionsByUpload(ctx, entry.AccountID, entry.UploadID)
+		if err != nil {
+			return &SummaryReport{}, fmt.Errorf("could not load transactions of outbox entry %d: %w", entry.ID, err)
+		}
+		jobs[i] = SummaryJob{AccountID: entry.AccountID, Transactions: transactions}
+	}
+
+	report, sendErr := uc.SendSummaryEmail.SendAll(ctx, jobs)
+
+	// Record the outcomes even when ctx was canceled, so nothing is sent twice
+	recordCtx := context.WithoutCancel(ctx)
+	now := time.Now()
+	for i, outcome := range report.Outcomes {
+		entry := uc.nextState(entries[i], outcome, now)
+		if err := uc.OutboxRepo.UpdateOutbox(recordCtx, entry); err != nil {
+			return report, err
+		}
+	}
+	return report, sendErr
+}
+
+// nextState applies the outcome of a delivery attempt to an outbox entry.
+func (uc *DispatchOutbox) nextState(entry entities.OutboxEntry, outcome AccountOutcome, now time.Time) entities.OutboxEntry {
+	switch {
+	case outcome.Status == SummarySent:
+		entry.Status = entities.OutboxSent
+		entry.Attempts++
+		entry.LastError = ""
+		entry.SentAt = now
+	case outcome.Status == SummarySkippedUnknownAccount:
+		// Retrying cannot help until the account exists; resend it then
+		entry.Status = entities.OutboxFailed
+		entry.Attempts++
+		entry.LastError = outcome.Err.Error()
+	case errors.Is(outcome.Err, ErrSummaryNotStarted):
+		// Never attempted: due again right away, without using up an attempt.
+		// A send cut short by ctx may have reached the server, so it counts.
+		entry.NextAttemptAt = now
+	default:
+		entry.Attempts++
+		entry.LastError = outcome.Err.Error()
+		if entry.Attempts >= uc.MaxAttempts {
+			entry.Status = entities.OutboxFailed
+			log.Printf("Giving up on summary of account %s for %s after %d attempts", entry.AccountID, entry.UploadID, entry.Attempts)
+		} else {
+			entry.NextAttemptAt = now.Add(uc.backoff(entry.Attempts))
+		}
+	}
+	return entry
+}
+
+// backoff returns the delay after the given number of failed attempts:
+// BaseDelay, then twice as long after each failure, up to MaxDelay.
+func (uc *DispatchOutbox) backoff(attempts int) time.Duration {
+	delay := uc.BaseDelay
+	for i := 1; i < attempts && delay < uc.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, uc.MaxDelay)
+}
@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+)
+
+func TestDispatchOutboxNextState(t *testing.T) {
+	now := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	errSend := errors.New("421 try again later")
+
+	tests := []struct {
+		name         string
+		attempts     int
+		outcome      AccountOutcome
+		wantStatus   entities.OutboxStatus
+		wantAttempts int
+		wantNext     time.Time
+		wantError    string
+	}{
+		{
+			name:         "sent",
+			attempts:     2,
+			outcome:      AccountOutcome{Status: SummarySent},
+			wantStatus:   entities.OutboxSent,
+			wantAttempts: 3,
+			wantNext:     now.Add(-time.Hour),
+		},
+		{
+			name:         "unknown account fails without retries",
+			outcome:      AccountOutcome{Status: SummarySkippedUnknownAccount, Err: entities.ErrAccountNotFound},
+			wantStatus:   entities.OutboxFailed,
+			wantAttempts: 1,
+			wantNext:     now.Add(-time.Hour),
+			wantError:    entities.ErrAccountNotFound.Error(),
+		},
+		{
+			name:         "not started is due again without using an attempt",
+			attempts:     1,
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: fmt.Errorf("%w: %w", ErrSummaryNotStarted, context.Canceled)},
+			wantStatus:   entities.OutboxPending,
+			wantAttempts: 1,
+			wantNext:     now,
+		},
+		{
+			name:         "canceled while sending counts as an attempt",
+			attempts:     1,
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: context.Canceled},
+			wantStatus:   entities.OutboxPending,
+			wantAttempts: 2,
+			wantNext:     now.Add(2 * time.Minute),
+			wantError:    context.Canceled.Error(),
+		},
+		{
+			name:         "deadline while sending counts as an attempt",
+			attempts:     4,
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: fmt.Errorf("could not send email: %w", context.DeadlineExceeded)},
+			wantStatus:   entities.OutboxFailed,
+			wantAttempts: 5,
+			wantNext:     now.Add(-time.Hour),
+			wantError:    "could not send email: " + context.DeadlineExceeded.Error(),
+		},
+		{
+			name:         "first failure waits the base delay",
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: errSend},
+			wantStatus:   entities.OutboxPending,
+			wantAttempts: 1,
+			wantNext:     now.Add(time.Minute),
+			wantError:    errSend.Error(),
+		},
+		{
+			name:         "third failure waits four times the base delay",
+			attempts:     2,
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: errSend},
+			wantStatus:   entities.OutboxPending,
+			wantAttempts: 3,
+			wantNext:     now.Add(4 * time.Minute),
+			wantError:    errSend.Error(),
+		},
+		{
+			name:         "last attempt fails the entry",
+			attempts:     4,
+			outcome:      AccountOutcome{Status: SummaryFailed, Err: errSend},
+			wantStatus:   entities.OutboxFailed,
+			wantAttempts: 5,
+			wantNext:     now.Add(-time.Hour),
+			wantError:    errSend.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewDispatchOutbox(nil, nil, nil)
+			entry := entities.OutboxEntry{
+				AccountID:     "1",
+				Status:        entities.OutboxPending,
+				Attempts:      tt.attempts,
+				NextAttemptAt: now.Add(-time.Hour),
+				LastError:     "earlier error",
+			}
+			if tt.wantStatus == entities.OutboxSent {
+				tt.wantError = ""
+			} else if tt.wantError == "" {
+				tt.wantError = entry.LastError
+			}
+
+			got := uc.nextState(entry, tt.outcome, now)
+			if got.Status != tt.wantStatus || got.Attempts != tt.wantAttempts || !got.NextAttemptAt.Equal(tt.wantNext) || got.LastError != tt.wantError {
+				t.Errorf("nextState = %s, %d attempts, next at %s, error %q, want %s, %d attempts, next at %s, error %q",
+					got.Status, got.Attempts, got.NextAttemptAt.Format(time.TimeOnly), got.LastError,
+					tt.wantStatus, tt.wantAttempts, tt.wantNext.Format(time.TimeOnly), tt.wantError)
+			}
+			if sent := !got.SentAt.IsZero(); sent != (tt.wantStatus == entities.OutboxSent) {
+				t.Errorf("SentAt = %s, want it set only when sent", got.SentAt)
+			}
+		})
+	}
+}
+
+func TestDispatchOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 6, want: 32 * time.Minute},
+		{attempts: 7, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	uc := NewDispatchOutbox(nil, nil, nil)
+	for _, tt := range tests {
+		if got := uc.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchOutboxExecuteUpload(t *testing.T) {
+	errSend := errors.New("421 try again later")
+
+	tests := []struct {
+		name         string
+		fail         map[string]error
+		cancel       bool
+		wantSent     []string
+		wantPending  []string // Accounts of upload "a" still pending
+		wantAttempts int      // Attempts of the pending entries
+	}{
+		{
+			name:     "only the summaries of the upload are delivered",
+			wantSent: []string{"one@example.com", "two@example.com"},
+		},
+		{
+			name:         "failed deliveries are scheduled for later",
+			fail:         map[string]error{"two@example.com": errSend},
+			wantSent:     []string{"one@example.com"},
+			wantPending:  []string{"2"},
+			wantAttempts: 1,
+		},
+		{
+			name:        "summaries not started are due again without using an attempt",
+			cancel:      true,
+			wantPending: []string{"1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo()
+			now := time.Now()
+			var transactions []entities.Transaction
+			var outbox []entities.OutboxEntry
+			for _, upload := range []string{"a", "b"} {
+				for _, account := range []string{"1", "2"} {
+					transaction := testTransaction(upload+account, account, "2024-07-15", "60.5")
+					transaction.UploadID = upload
+					transactions = append(transactions, transaction)
+					outbox = append(outbox, entities.OutboxEntry{UploadID: upload, AccountID: account, Status: entities.OutboxPending, NextAttemptAt: now, CreatedAt: now})
+				}
+			}
+			if err := saveUpload(repo, transactions, outbox); err != nil {
+				t.Fatal(err)
+			}
+
+			sender := &fakeEmailSender{fail: tt.fail}
+			uc := NewDispatchOutbox(repo, repo, NewSendSummaryEmail(NewGenerateSummary(repo), sender, fakeRenderer{}))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			report, err := uc.ExecuteUpload(ctx, "a")
+			if tt.cancel != (err != nil) {
+				t.Fatalf("ExecuteUpload() error = %v, want error %v", err, tt.cancel)
+			}
+			if len(report.Outcomes) != 2 {
+				t.Fatalf("got %d outcomes, want 2", len(report.Outcomes))
+			}
+			sent := sender.sentTo()
+			slices.Sort(sent)
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("sent to %v, want %v", sent, tt.wantSent)
+			}
+
+			// Upload "b" is untouched and the pending entries of "a" are due
+			// again once their backoff expires
+			pending, err := repo.ClaimOutbox(context.Background(), "", now.Add(2*time.Hour), time.Minute, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var pendingA []string
+			for _, entry := range pending {
+				if entry.UploadID == "b" {
+					if entry.Attempts != 0 {
+						t.Errorf("entry of upload b has %d attempts, want 0", entry.Attempts)
+					}
+					continue
+				}
+				pendingA = append(pendingA, entry.AccountID)
+				if entry.Attempts != tt.wantAttempts {
+					t.Errorf("entry of account %s has %d attempts, want %d", entry.AccountID, entry.Attempts, tt.wantAttempts)
+				}
+			}
+			if len(pending)-len(pendingA) != 2 {
+				t.Errorf("%d entries of upload b pending, want 2", len(pending)-len(pendingA))
+			}
+			if !slices.Equal(pendingA, tt.wantPending) {
+				t.Errorf("pending accounts of upload a = %v, want %v", pendingA, tt.wantPending)
+			}
+		})
+	}
+}
@@ -1,104 +1,194 @@
 package usecases
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"transactions-summary/internal/entities"
 	"transactions-summary/internal/interfaces"
 )
 
+// SummaryMode decides which transactions a summary covers.
+type SummaryMode string
+
+const (
+	// SummaryModeUpload summarizes only the transactions of the current upload.
+	SummaryModeUpload SummaryMode = "upload"
+	// SummaryModeLifetime summarizes every stored transaction of the account
+	// together with the current upload.
+	SummaryModeLifetime SummaryMode = "lifetime"
+)
+
+// ParseSummaryMode converts a mode name to a SummaryMode.
+// An empty name selects SummaryModeUpload.
+func ParseSummaryMode(name string) (SummaryMode, error) {
+	switch mode := SummaryMode(name); mode {
+	case "":
+		return SummaryModeUpload, nil
+	case SummaryModeUpload, SummaryModeLifetime:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown summary mode %q (expected %s or %s)", name, SummaryModeUpload, SummaryModeLifetime)
+	}
+}
+
 // GenerateSummary processes transactions and creates a summary.
 type GenerateSummary struct {
 	TransactionRepo interfaces.TransactionRepository
+	Mode            SummaryMode
 }
 
 // NewGenerateSummary creates a new GenerateSummary use case.
+// Summaries cover only the given transactions unless another Mode is set.
 func NewGenerateSummary(repo interfaces.TransactionRepository) *GenerateSummary {
 	return &GenerateSummary{
 		TransactionRepo: repo,
+		Mode:            SummaryModeUpload,
 	}
 }
 
-// Execute calculates the summary from all transactions in the database.
-func (uc *GenerateSummary) Execute(accountId string, transactions []entities.Transaction) (*entities.SummaryResult, string, error) {
+// Execute calculates the summary of an account from the given transactions.
+// In SummaryModeLifetime the account history stored in the database is added,
+// so the summary shows the real running totals. Totals and monthly breakdowns
+// are calculated separately for each currency.
+func (uc *GenerateSummary) Execute(ctx context.Context, accountId string, transactions []entities.Transaction) (*entities.SummaryResult, string, error) {
+	account, err := uc.TransactionRepo.GetAccount(ctx, accountId)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not retrieve account %s: %w", accountId, err)
+	}
+
+	if uc.Mode == SummaryModeLifetime {
+		history, err := uc.TransactionRepo.ListTransactionsByAccount(ctx, accountId, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, "", fmt.Errorf("could not retrieve history of account %s: %w", accountId, err)
+		}
+		transactions = mergeTransactions(history, transactions)
+	}
+
+	// Group the transactions by currency
+	byCurrency := make(map[string][]entities.Transaction)
+	var currencies []string
+	for _, transaction := range transactions {
+		currency := transaction.Amount.Currency
+		if _, exists := byCurrency[currency]; !exists {
+			currencies = append(currencies, currency)
+		}
+		byCurrency[currency] = append(byCurrency[currency], transaction)
+	}
+
+	// List the account currency first, then the others alphabetically
+	sort.Slice(currencies, func(i, j int) bool {
+		if (currencies[i] == account.Currency) != (currencies[j] == account.Currency) {
+			return currencies[i] == account.Currency
+		}
+		return currencies[i] < currencies[j]
+	})
+
+	result := &entities.SummaryResult{AccountID: account.ID, Locale: account.Locale, Transactions: transactions}
+	for _, currency := range currencies {
+		result.Currencies = append(result.Currencies, summarizeCurrency(currency, byCurrency[currency]))
+	}
+
+	return result, account.Email, nil
+}
+
+// summarizeCurrency calculates the totals and monthly breakdown of transactions
+// that all share the same currency.
+func summarizeCurrency(currency string, transactions []entities.Transaction) entities.CurrencySummary {
 	// Calculate summary data
-	totalCredit := 0.0
-	totalDebit := 0.0
+	totalCredit := entities.NewMoney(0, currency)
+	totalDebit := entities.NewMoney(0, currency)
 	monthlyData := make(map[string]*entities.MonthlySummary)
+	credits := make(map[string]int) // Credit transactions by month
+	debits := make(map[string]int)  // Debit transactions by month
 
 	// Process each transaction
 	for _, transaction := range transactions {
 
-		// Get month name (e.g., "July")
-		monthName := transaction.TransactionDate.Format("January")
+		// Get year and month key (e.g., "2024-07")
+		key := monthKey(transaction)
 
 		// Initialize monthly summary if not present
-		if _, exists := monthlyData[monthName]; !exists {
-			monthlyData[monthName] = &entities.MonthlySummary{
-				Month: monthName,
+		if _, exists := monthlyData[key]; !exists {
+			monthlyData[key] = &entities.MonthlySummary{
+				Month:         transaction.TransactionDate.Format("January"),
+				MonthNumber:   int(transaction.TransactionDate.Month()),
+				Year:          transaction.TransactionDate.Year(),
+				AverageCredit: entities.NewMoney(0, currency),
+				AverageDebit:  entities.NewMoney(0, currency),
+				TotalCredits:  entities.NewMoney(0, currency),
+				TotalDebits:   entities.NewMoney(0, currency),
 			}
 		}
 
 		// Update monthly data
-		monthlySummary := monthlyData[monthName]
+		monthlySummary := monthlyData[key]
 		monthlySummary.NumTransactions++
 
 		if transaction.Type == "credit" {
-			totalCredit += transaction.Amount
-			monthlySummary.TotalCredits += transaction.Amount
+			totalCredit = totalCredit.Add(transaction.Amount)
+			monthlySummary.TotalCredits = monthlySummary.TotalCredits.Add(transaction.Amount)
+			credits[key]++
 		} else if transaction.Type == "debit" {
-			totalDebit += transaction.Amount
-			monthlySummary.TotalDebits += transaction.Amount
+			totalDebit = totalDebit.Add(transaction.Amount)
+			monthlySummary.TotalDebits = monthlySummary.TotalDebits.Add(transaction.Amount)
+			debits[key]++
 		}
 	}
 
-	// Calculate averages for each month
+	// Visit the months in chronological order ("2006-01" keys sort by date)
+	keys := make([]string, 0, len(monthlyData))
+	for key := range monthlyData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Calculate averages and the running balance for each month
 	var monthlySummaries []entities.MonthlySummary
-	for _, summary := range monthlyData {
-		if summary.NumTransactions > 0 {
-			// Calculate averages
-			creditCount := float64(countCredits(transactions, summary.Month))
-			debitCount := float64(countDebits(transactions, summary.Month))
-
-			if creditCount > 0 {
-				summary.AverageCredit = summary.TotalCredits / creditCount
-			}
-			if debitCount > 0 {
-				summary.AverageDebit = summary.TotalDebits / debitCount
-			}
+	balance := entities.NewMoney(0, currency)
+	for _, key := range keys {
+		summary := monthlyData[key]
+		// Calculate averages
+		if credits[key] > 0 {
+			summary.AverageCredit = summary.TotalCredits.Div(credits[key])
+		}
+		if debits[key] > 0 {
+			summary.AverageDebit = summary.TotalDebits.Div(debits[key])
 		}
+		summary.NetAmount = summary.TotalCredits.Add(summary.TotalDebits)
+		balance = balance.Add(summary.NetAmount)
+		summary.ClosingBalance = balance
 		monthlySummaries = append(monthlySummaries, *summary)
 	}
 
-	account, err := uc.TransactionRepo.GetAccount(accountId)
-	if err != nil {
-		return nil, "", fmt.Errorf("could not retrieve account %s: %v", accountId, err)
-	}
-
-	return &entities.SummaryResult{
+	return entities.CurrencySummary{
+		Currency:         currency,
 		TotalCredit:      totalCredit,
 		TotalDebit:       totalDebit,
 		MonthlySummaries: monthlySummaries,
-	}, account.Email, nil
+	}
 }
 
-// Helper functions for counting transactions by type
-func countCredits(transactions []entities.Transaction, month string) int {
-	count := 0
+// mergeTransactions combines the stored history with new transactions. The new
+// transactions are usually stored already, so duplicates are dropped by ID.
+func mergeTransactions(history, transactions []entities.Transaction) []entities.Transaction {
+	merged := make([]entities.Transaction, 0, len(history)+len(transactions))
+	seen := make(map[string]bool, len(history))
+	for _, transaction := range history {
+		seen[transaction.ID] = true
+		merged = append(merged, transaction)
+	}
 	for _, transaction := range transactions {
-		if transaction.TransactionDate.Format("January") == month && transaction.Type == "credit" {
-			count++
+		if !seen[transaction.ID] {
+			merged = append(merged, transaction)
 		}
 	}
-	return count
+	return merged
 }
 
-func countDebits(transactions []entities.Transaction, month string) int {
-	count := 0
-	for _, transaction := range transactions {
-		if transaction.TransactionDate.Format("January") == month && transaction.Type == "debit" {
-			count++
-		}
-	}
-	return count
+// monthKey returns the year and month a transaction belongs to (e.g., "2024-07").
+func monthKey(transaction entities.Transaction) string {
+	return transaction.TransactionDate.Format("2006-01")
 }
@@ -0,0 +1,296 @@
+package usecases
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/memory"
+	"transactions-summary/internal/interfaces"
+)
+
+// newTestRepo returns an in-memory repository with accounts "1" and "2".
+func newTestRepo() *memory.InMemoryTransactionRepo {
+	repo := memory.NewInMemoryTransactionRepo()
+	repo.SeedAccounts([]entities.Account{
+		{ID: "1", Email: "one@example.com"},
+		{ID: "2", Email: "two@example.com"},
+	})
+	return repo
+}
+
+// saveUpload stores transactions and outbox entries as one committed upload.
+func saveUpload(repo interfaces.TransactionRepository, transactions []entities.Transaction, outbox []entities.OutboxEntry) error {
+	writer, err := repo.BeginUpload(context.Background())
+	if err != nil {
+		return err
+	}
+	defer writer.Rollback()
+	if err := writer.SaveTransactions(context.Background(), transactions); err != nil {
+		return err
+	}
+	return writer.Commit(context.Background(), outbox)
+}
+
+// usd parses a decimal amount in US dollars.
+func usd(amount string) entities.Money {
+	money, err := entities.ParseMoney(amount, "USD")
+	if err != nil {
+		panic(err)
+	}
+	return money
+}
+
+// withCurrency returns transaction with its amount in another currency.
+func withCurrency(transaction entities.Transaction, currency string) entities.Transaction {
+	transaction.Amount.Currency = currency
+	return transaction
+}
+
+// testTransaction returns a transaction of account on the given date, typed
+// by the sign of its amount.
+func testTransaction(id, account, date, amount string) entities.Transaction {
+	transactionDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	money := usd(amount)
+	transactionType := "credit"
+	if money.IsNegative() {
+		transactionType = "debit"
+	}
+	return entities.Transaction{ID: id, AccountID: account, Amount: money, TransactionDate: transactionDate, Type: transactionType}
+}
+
+func TestGenerateSummaryExecute(t *testing.T) {
+	tests := []struct {
+		name           string
+		account        string
+		transactions   []entities.Transaction
+		wantCurrencies []string
+		wantCredit     entities.Money // Totals and months of the first currency
+		wantDebit      entities.Money
+		wantMonths     []entities.MonthlySummary
+		wantErr        bool
+	}{
+		{
+			name:    "one month",
+			account: "1",
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("b", "1", "2024-07-28", "-10.25"),
+				testTransaction("c", "1", "2024-07-30", "20.5"),
+			},
+			wantCurrencies: []string{"USD"},
+			wantCredit:     usd("81"),
+			wantDebit:      usd("-10.25"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 3, AverageCredit: usd("40.5"), AverageDebit: usd("-10.25"), TotalCredits: usd("81"), TotalDebits: usd("-10.25"), NetAmount: usd("70.75"), ClosingBalance: usd("70.75")},
+			},
+		},
+		{
+			name:    "averages are per month and type",
+			account: "2",
+			transactions: []entities.Transaction{
+				testTransaction("a", "2", "2024-07-15", "10"),
+				testTransaction("b", "2", "2024-08-02", "-20.5"),
+				testTransaction("c", "2", "2024-08-13", "-10"),
+				testTransaction("d", "2", "2024-08-20", "5"),
+			},
+			wantCurrencies: []string{"USD"},
+			wantCredit:     usd("15"),
+			wantDebit:      usd("-30.5"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 1, AverageCredit: usd("10"), AverageDebit: usd("0"), TotalCredits: usd("10"), TotalDebits: usd("0"), NetAmount: usd("10"), ClosingBalance: usd("10")},
+				{Month: "August", MonthNumber: 8, Year: 2024, NumTransactions: 3, AverageCredit: usd("5"), AverageDebit: usd("-15.25"), TotalCredits: usd("5"), TotalDebits: usd("-30.5"), NetAmount: usd("-25.5"), ClosingBalance: usd("-15.5")},
+			},
+		},
+		{
+			name:    "one summary per currency, the account currency first",
+			account: "1",
+			transactions: []entities.Transaction{
+				withCurrency(testTransaction("a", "1", "2024-07-15", "5"), "MXN"),
+				withCurrency(testTransaction("b", "1", "2024-07-16", "-7"), "EUR"),
+				testTransaction("c", "1", "2024-07-17", "10"),
+			},
+			wantCurrencies: []string{"USD", "EUR", "MXN"},
+			wantCredit:     usd("10"),
+			wantDebit:      usd("0"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 1, AverageCredit: usd("10"), AverageDebit: usd("0"), TotalCredits: usd("10"), TotalDebits: usd("0"), NetAmount: usd("10"), ClosingBalance: usd("10")},
+			},
+		},
+		{
+			name:    "months in chronological order across years",
+			account: "1",
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2025-01-03", "-4"),
+				testTransaction("b", "1", "2024-12-30", "10"),
+				testTransaction("c", "1", "2024-01-15", "1"),
+			},
+			wantCurrencies: []string{"USD"},
+			wantCredit:     usd("11"),
+			wantDebit:      usd("-4"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "January", MonthNumber: 1, Year: 2024, NumTransactions: 1, AverageCredit: usd("1"), AverageDebit: usd("0"), TotalCredits: usd("1"), TotalDebits: usd("0"), NetAmount: usd("1"), ClosingBalance: usd("1")},
+				{Month: "December", MonthNumber: 12, Year: 2024, NumTransactions: 1, AverageCredit: usd("10"), AverageDebit: usd("0"), TotalCredits: usd("10"), TotalDebits: usd("0"), NetAmount: usd("10"), ClosingBalance: usd("11")},
+				{Month: "January", MonthNumber: 1, Year: 2025, NumTransactions: 1, AverageCredit: usd("0"), AverageDebit: usd("-4"), TotalCredits: usd("0"), TotalDebits: usd("-4"), NetAmount: usd("-4"), ClosingBalance: usd("7")},
+			},
+		},
+		{
+			name:    "no transactions",
+			account: "1",
+		},
+		{
+			name:         "unknown account",
+			account:      "3",
+			transactions: []entities.Transaction{testTransaction("a", "3", "2024-07-15", "10")},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewGenerateSummary(newTestRepo())
+			summary, email, err := uc.Execute(context.Background(), tt.account, tt.transactions)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Execute() returned no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if wantEmail := map[string]string{"1": "one@example.com", "2": "two@example.com"}[tt.account]; email != wantEmail {
+				t.Errorf("email = %q, want %q", email, wantEmail)
+			}
+			if len(summary.Currencies) != len(tt.wantCurrencies) {
+				t.Fatalf("got %d currencies, want %v: %+v", len(summary.Currencies), tt.wantCurrencies, summary.Currencies)
+			}
+			for i, currency := range tt.wantCurrencies {
+				if summary.Currencies[i].Currency != currency {
+					t.Errorf("currency %d = %s, want %s", i, summary.Currencies[i].Currency, currency)
+				}
+			}
+			if len(tt.wantCurrencies) == 0 {
+				return
+			}
+
+			first := summary.Currencies[0]
+			if first.TotalCredit != tt.wantCredit || first.TotalDebit != tt.wantDebit {
+				t.Errorf("totals = %v and %v, want %v and %v", first.TotalCredit, first.TotalDebit, tt.wantCredit, tt.wantDebit)
+			}
+			if len(first.MonthlySummaries) != len(tt.wantMonths) {
+				t.Fatalf("got %d months, want %d: %+v", len(first.MonthlySummaries), len(tt.wantMonths), first.MonthlySummaries)
+			}
+			for i, month := range first.MonthlySummaries {
+				if month != tt.wantMonths[i] {
+					t.Errorf("month %d = %+v, want %+v", i, month, tt.wantMonths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSummaryModes(t *testing.T) {
+	// Account 1 keeps a history from an earlier year and an earlier upload
+	history := []entities.Transaction{
+		testTransaction("h1", "1", "2023-07-10", "100"),
+		testTransaction("h2", "1", "2024-07-05", "30"),
+		testTransaction("h3", "1", "2024-07-06", "-12"),
+		withCurrency(testTransaction("h4", "1", "2024-07-07", "50"), "MXN"),
+	}
+	upload := []entities.Transaction{
+		testTransaction("u1", "1", "2024-07-20", "-8"),
+		testTransaction("u2", "1", "2024-08-01", "20"),
+		testTransaction("u3", "1", "2024-08-02", "10"),
+	}
+
+	tests := []struct {
+		name           string
+		mode           SummaryMode
+		storeUpload    bool // Whether the upload was stored before the summary
+		wantCurrencies []string
+		wantCredit     entities.Money
+		wantDebit      entities.Money
+		wantMonths     []entities.MonthlySummary // USD months, in chronological order
+	}{
+		{
+			name:           "upload",
+			mode:           SummaryModeUpload,
+			storeUpload:    true,
+			wantCurrencies: []string{"USD"},
+			wantCredit:     usd("30"),
+			wantDebit:      usd("-8"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 1, AverageCredit: usd("0"), AverageDebit: usd("-8"), TotalCredits: usd("0"), TotalDebits: usd("-8"), NetAmount: usd("-8"), ClosingBalance: usd("-8")},
+				{Month: "August", MonthNumber: 8, Year: 2024, NumTransactions: 2, AverageCredit: usd("15"), AverageDebit: usd("0"), TotalCredits: usd("30"), TotalDebits: usd("0"), NetAmount: usd("30"), ClosingBalance: usd("22")},
+			},
+		},
+		{
+			name:           "lifetime with the upload stored",
+			mode:           SummaryModeLifetime,
+			storeUpload:    true,
+			wantCurrencies: []string{"USD", "MXN"},
+			wantCredit:     usd("160"),
+			wantDebit:      usd("-20"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2023, NumTransactions: 1, AverageCredit: usd("100"), AverageDebit: usd("0"), TotalCredits: usd("100"), TotalDebits: usd("0"), NetAmount: usd("100"), ClosingBalance: usd("100")},
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 3, AverageCredit: usd("30"), AverageDebit: usd("-10"), TotalCredits: usd("30"), TotalDebits: usd("-20"), NetAmount: usd("10"), ClosingBalance: usd("110")},
+				{Month: "August", MonthNumber: 8, Year: 2024, NumTransactions: 2, AverageCredit: usd("15"), AverageDebit: usd("0"), TotalCredits: usd("30"), TotalDebits: usd("0"), NetAmount: usd("30"), ClosingBalance: usd("140")},
+			},
+		},
+		{
+			name:           "lifetime with the upload not stored",
+			mode:           SummaryModeLifetime,
+			wantCurrencies: []string{"USD", "MXN"},
+			wantCredit:     usd("160"),
+			wantDebit:      usd("-20"),
+			wantMonths: []entities.MonthlySummary{
+				{Month: "July", MonthNumber: 7, Year: 2023, NumTransactions: 1, AverageCredit: usd("100"), AverageDebit: usd("0"), TotalCredits: usd("100"), TotalDebits: usd("0"), NetAmount: usd("100"), ClosingBalance: usd("100")},
+				{Month: "July", MonthNumber: 7, Year: 2024, NumTransactions: 3, AverageCredit: usd("30"), AverageDebit: usd("-10"), TotalCredits: usd("30"), TotalDebits: usd("-20"), NetAmount: usd("10"), ClosingBalance: usd("110")},
+				{Month: "August", MonthNumber: 8, Year: 2024, NumTransactions: 2, AverageCredit: usd("15"), AverageDebit: usd("0"), TotalCredits: usd("30"), TotalDebits: usd("0"), NetAmount: usd("30"), ClosingBalance: usd("140")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo()
+			stored := history
+			if tt.storeUpload {
+				stored = append(append([]entities.Transaction{}, history...), upload...)
+			}
+			if err := saveUpload(repo, stored, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			uc := NewGenerateSummary(repo)
+			uc.Mode = tt.mode
+			summary, _, err := uc.Execute(context.Background(), "1", upload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var currencies []string
+			for _, currencySummary := range summary.Currencies {
+				currencies = append(currencies, currencySummary.Currency)
+			}
+			if !slices.Equal(currencies, tt.wantCurrencies) {
+				t.Fatalf("currencies = %v, want %v", currencies, tt.wantCurrencies)
+			}
+
+			first := summary.Currencies[0]
+			if first.TotalCredit != tt.wantCredit || first.TotalDebit != tt.wantDebit {
+				t.Errorf("totals = %v and %v, want %v and %v", first.TotalCredit, first.TotalDebit, tt.wantCredit, tt.wantDebit)
+			}
+			if !slices.Equal(first.MonthlySummaries, tt.wantMonths) {
+				t.Errorf("months = %+v, want %+v", first.MonthlySummaries, tt.wantMonths)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// UploadStatus is what is known about an uploaded file: every time it was
+// processed and the summary emails its transactions owe.
+type UploadStatus struct {
+	Source string                  `json:"source"`
+	Jobs   []entities.IngestionJob `json:"jobs"`   // Newest first
+	Emails []entities.OutboxEntry  `json:"emails"` // One per account and job, newest job first
+}
+
+// GetUploadStatus is a use case that looks up the status of an upload.
+type GetUploadStatus struct {
+	JobRepo    interfaces.IngestionJobRepository
+	OutboxRepo interfaces.OutboxRepository
+}
+
+// NewGetUploadStatus creates a new GetUploadStatus use case.
+func NewGetUploadStatus(jobRepo interfaces.IngestionJobRepository, outboxRepo interfaces.OutboxRepository) *GetUploadStatus {
+	return &GetUploadStatus{
+		JobRepo:    jobRepo,
+		OutboxRepo: outboxRepo,
+	}
+}
+
+// Execute returns the status of every upload whose object key or source is
+// key, most recently processed first. The same key uploaded to several
+// buckets has one status per bucket. It returns entities.ErrUploadNotFound
+// when no job matches.
+func (uc *GetUploadStatus) Execute(ctx context.Context, key string) ([]UploadStatus, error) {
+	jobs, err := uc.JobRepo.FindJobs(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not look up upload %s: %w", key, err)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("%w: %s", entities.ErrUploadNotFound, key)
+	}
+
+	var statuses []UploadStatus
+	index := make(map[string]int)
+	for _, job := range jobs {
+		i, exists := index[job.Source]
+		if !exists {
+			i = len(statuses)
+			index[job.Source] = i
+			statuses = append(statuses, UploadStatus{Source: job.Source})
+		}
+		statuses[i].Jobs = append(statuses[i].Jobs, job)
+	}
+
+	for i := range statuses {
+		for _, job := range statuses[i].Jobs {
+			emails, err := uc.OutboxRepo.ListOutbox(ctx, job.UploadID())
+			if err != nil {
+				return nil, fmt.Errorf("could not look up summary emails of job %d: %w", job.ID, err)
+			}
+			statuses[i].Emails = append(statuses[i].Emails, emails...)
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// IngestUpload is a use case that processes an uploaded file, sends the
+// summary emails it enqueued and records the whole run as an ingestion job.
+type IngestUpload struct {
+	JobRepo             interfaces.IngestionJobRepository
+	ProcessTransactions *ProcessTransactions
+	DispatchOutbox      *DispatchOutbox
+}
+
+// NewIngestUpload creates a new IngestUpload use case.
+func NewIngestUpload(jobRepo interfaces.IngestionJobRepository, processTransactions *ProcessTransactions, dispatchOutbox *DispatchOutbox) *IngestUpload {
+	return &IngestUpload{
+		JobRepo:             jobRepo,
+		ProcessTransactions: processTransactions,
+		DispatchOutbox:      dispatchOutbox,
+	}
+}
+
+// Execute processes the uploaded file and then delivers the summary emails it
+// enqueued; emails owed by other uploads are left to the dispatch command. The
+// job must have its key set and takes its source from file; its status, row
+// counts and timings are recorded as the upload goes. Failing to record the
+// job is logged and never stops the upload itself.
+func (uc *IngestUpload) Execute(ctx context.Context, job *entities.IngestionJob, file entities.FileInfo, input io.Reader) (*SummaryReport, error) {
+	job.Source = file.Source
+	uc.start(ctx, job)
+
+	// Key the stored transactions and emails on this job, so re-uploading the
+	// same key never mixes in the rows of an earlier upload
+	uploadID := job.UploadID()
+	if job.ID == 0 {
+		uploadID = "upload:" + uuid.NewString()
+	}
+	file.UploadID = uploadID
+
+	result, err := uc.ProcessTransactions.Execute(ctx, file, input)
+	if result != nil {
+		job.RowsRead = result.RowsRead
+		job.RowsDuplicate = result.RowsDuplicate
+		job.RowsInserted = result.RowsInserted
+		job.RowsRejected = result.RowsRejected
+	}
+	if err != nil {
+		err = fmt.Errorf("could not process transactions: %w", err)
+		uc.finish(ctx, job, entities.JobFailed, err)
+		return nil, err
+	}
+	job.ProcessedAt = time.Now()
+	log.Printf("Transactions processed successfully: %d read, %d duplicate, %d inserted, %d rejected",
+		job.RowsRead, job.RowsDuplicate, job.RowsInserted, job.RowsRejected)
+
+	// The transactions are stored, so the job is processed even when sending
+	// fails: the emails stay in the outbox and are retried later
+	report, err := uc.DispatchOutbox.ExecuteUpload(ctx, uploadID)
+	if err != nil {
+		err = fmt.Errorf("could not send summary emails: %w", err)
+	}
+	uc.finish(ctx, job, entities.JobProcessed, err)
+	return report, err
+}
+
+// Fail records a job for an upload that could not be processed at all, e.g.
+// because it could not be read.
+func (uc *IngestUpload) Fail(ctx context.Context, job *entities.IngestionJob, err error) {
+	uc.start(ctx, job)
+	uc.finish(ctx, job, entities.JobFailed, err)
+}
+
+// start stores job as a new processing job.
+func (uc *IngestUpload) start(ctx context.Context, job *entities.IngestionJob) {
+	job.Status = entities.JobProcessing
+	job.StartedAt = time.Now()
+	if err := uc.JobRepo.CreateJob(ctx, job); err != nil {
+		log.Printf("Could not record ingestion job for %s: %v", job.Source, err)
+	}
+}
+
+// finish stores the final state of job. It uses a context that is not
+// canceled with ctx, so a job cut short by a deadline is still recorded.
+func (uc *IngestUpload) finish(ctx context.Context, job *entities.IngestionJob, status entities.JobStatus, err error) {
+	job.Status = status
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if job.ID == 0 {
+		return
+	}
+	if err := uc.JobRepo.UpdateJob(context.WithoutCancel(ctx), *job); err != nil {
+		log.Printf("Could not record ingestion job %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"transactions-summary/internal/entities"
+)
+
+func TestIngestUploadExecute(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo()
+	sender := &fakeEmailSender{}
+	dispatchOutbox := NewDispatchOutbox(repo, repo, NewSendSummaryEmail(NewGenerateSummary(repo), sender, fakeRenderer{}))
+	a := testTransaction("a", "1", "2024-07-15", "60.5")
+	b := testTransaction("b", "2", "2024-07-28", "-10.3")
+	c := testTransaction("c", "1", "2024-08-02", "-20.46")
+
+	tests := []struct {
+		name         string
+		transactions []entities.Transaction
+		readErr      error
+		wantStatus   entities.JobStatus
+		wantRows     [4]int // Read, duplicate, inserted and rejected
+		wantSent     []string
+	}{
+		{
+			name:         "new upload",
+			transactions: []entities.Transaction{a, b},
+			wantStatus:   entities.JobProcessed,
+			wantRows:     [4]int{2, 0, 2, 0},
+			wantSent:     []string{"one@example.com", "two@example.com"},
+		},
+		{
+			name:         "same key again is a separate upload",
+			transactions: []entities.Transaction{a, b, c},
+			wantStatus:   entities.JobProcessed,
+			wantRows:     [4]int{3, 2, 1, 0},
+			wantSent:     []string{"one@example.com"},
+		},
+		{
+			name:       "unreadable file",
+			readErr:    errors.New("bad header"),
+			wantStatus: entities.JobFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender.sent = nil
+			processTransactions := NewProcessTransactions(repo, &fakeReader{transactions: tt.transactions, err: tt.readErr})
+			uc := NewIngestUpload(repo, processTransactions, dispatchOutbox)
+
+			job := &entities.IngestionJob{Key: "upload.csv"}
+			_, err := uc.Execute(ctx, job, entities.FileInfo{Source: "s3://bucket/upload.csv"}, strings.NewReader(""))
+			if (err != nil) != (tt.readErr != nil) {
+				t.Fatalf("Execute() error = %v, want error %v", err, tt.readErr != nil)
+			}
+
+			jobs, err := repo.FindJobs(ctx, "upload.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := jobs[0]
+			if got.ID != job.ID || got.Status != tt.wantStatus || got.FinishedAt.IsZero() {
+				t.Errorf("job %d = %s, finished at %s, want job %d %s and finished", got.ID, got.Status, got.FinishedAt, job.ID, tt.wantStatus)
+			}
+			if rows := [4]int{got.RowsRead, got.RowsDuplicate, got.RowsInserted, got.RowsRejected}; rows != tt.wantRows {
+				t.Errorf("job rows = %v, want %v", rows, tt.wantRows)
+			}
+			if tt.readErr != nil && !strings.Contains(got.Error, tt.readErr.Error()) {
+				t.Errorf("job error = %q, want it to contain %q", got.Error, tt.readErr)
+			}
+
+			// Only the emails of this job are sent, and the job owns them
+			sent := sender.sentTo()
+			slices.Sort(sent)
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("sent to %v, want %v", sent, tt.wantSent)
+			}
+			outbox, err := repo.ListOutbox(ctx, job.UploadID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(outbox) != len(tt.wantSent) {
+				t.Errorf("job has %d outbox entries, want %d", len(outbox), len(tt.wantSent))
+			}
+			for _, entry := range outbox {
+				if entry.Status != entities.OutboxSent {
+					t.Errorf("entry of account %s = %s, want %s", entry.AccountID, entry.Status, entities.OutboxSent)
+				}
+			}
+		})
+	}
+
+	// Each transaction keeps the upload that stored it
+	for id, want := range map[string]string{"a": "job:1", "c": "job:2"} {
+		transaction, err := repo.GetTransaction(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transaction.UploadID != want {
+			t.Errorf("transaction %s upload = %q, want %q", id, transaction.UploadID, want)
+		}
+	}
+}
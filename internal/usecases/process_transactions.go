@@ -1,64 +1,369 @@
 package usecases
 
 import (
-	"encoding/csv"
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"sort"
+	"time"
 
 	"transactions-summary/internal/entities"
 	"transactions-summary/internal/interfaces"
 )
 
-// ProcessTransactions processes transactions from a CSV file.
+// ValidationPolicy decides what happens to a file that contains invalid rows.
+type ValidationPolicy string
+
+const (
+	// PolicyRejectFile processes nothing when any row is invalid.
+	PolicyRejectFile ValidationPolicy = "reject-file"
+	// PolicySkipBadRows processes the valid rows and drops the invalid ones.
+	PolicySkipBadRows ValidationPolicy = "skip-bad-rows"
+	// PolicyQuarantine processes the valid rows and stores the invalid ones for later fixing.
+	PolicyQuarantine ValidationPolicy = "quarantine"
+)
+
+// ParseValidationPolicy converts a policy name to a ValidationPolicy.
+// An empty name selects PolicyRejectFile.
+func ParseValidationPolicy(name string) (ValidationPolicy, error) {
+	switch policy := ValidationPolicy(name); policy {
+	case "":
+		return PolicyRejectFile, nil
+	case PolicyRejectFile, PolicySkipBadRows, PolicyQuarantine:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown validation policy %q (expected %s, %s or %s)", name, PolicyRejectFile, PolicySkipBadRows, PolicyQuarantine)
+	}
+}
+
+// ProcessResult counts what happened to the rows of a file.
+type ProcessResult struct {
+	RowsRead      int // Data rows in the file, valid or not
+	RowsDuplicate int // Valid rows already stored or repeated in the file
+	RowsInserted  int
+	RowsRejected  int // Invalid and conflicting rows
+}
+
+// defaultChunkSize is the number of transactions saved at once by default.
+const defaultChunkSize = 1000
+
+// ProcessTransactions processes transactions from an uploaded file.
 type ProcessTransactions struct {
 	TransactionRepo interfaces.TransactionRepository
 	FileReader      interfaces.FileReader
+	Policy          ValidationPolicy
+	ErrorReporters  []interfaces.ErrorReporter
+	ChunkSize       int // Transactions held in memory before they are saved
 }
 
 // NewProcessTransactions creates a new ProcessTransactions use case.
+// Files with invalid rows are rejected unless another Policy is set.
 func NewProcessTransactions(repo interfaces.TransactionRepository, reader interfaces.FileReader) *ProcessTransactions {
 	return &ProcessTransactions{
 		TransactionRepo: repo,
 		FileReader:      reader,
+		Policy:          PolicyRejectFile,
+		ChunkSize:       defaultChunkSize,
+	}
+}
+
+// Execute reads the file, processes each transaction, and saves them to the database.
+// The file is streamed and saved in chunks of ChunkSize transactions, so memory
+// use does not grow with the file. The chunks are committed together at the
+// end, with one summary email per account in the outbox, so the upload is
+// stored completely or not at all.
+//
+// Transactions that are already stored, or repeated within the file, are skipped,
+// so processing the same source twice is a no-op. A transaction whose ID is
+// stored with a different account, date, amount or text is a conflict: it is
+// logged and, like an invalid row, rejects the file or is skipped depending on
+// the policy. Invalid rows are reported and then handled according to the
+// validation policy. A rejected file returns its row counts along with the
+// error. The stored transactions and summary emails are tagged with the file
+// UploadID, or its source when it has none.
+func (uc *ProcessTransactions) Execute(ctx context.Context, file entities.FileInfo, input io.Reader) (*ProcessResult, error) {
+	source := file.Source
+	uploadID := file.UploadID
+	if uploadID == "" {
+		uploadID = source
+	}
+	transactions, report := uc.FileReader.ReadTransactions(ctx, file, input)
+
+	writer, err := uc.TransactionRepo.BeginUpload(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not save transactions: %w", err)
+	}
+	defer writer.Rollback() // No-op once committed
+
+	chunkSize := uc.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	chunk := make([]entities.Transaction, 0, chunkSize)
+	upload := &uploadChunks{
+		repo:     uc.TransactionRepo,
+		writer:   writer,
+		report:   report,
+		uploadID: uploadID,
+		checkAll: uc.Policy == PolicyRejectFile,
+		known:    make(map[string]bool),
+		seen:     make(map[string]bool),
+	}
+	valid := 0
+
+	for transaction, err := range transactions {
+		if err != nil {
+			log.Printf("Could not read transactions: %v", err)
+			return nil, fmt.Errorf("could not read transactions: %w", err)
+		}
+		valid++
+
+		chunk = append(chunk, transaction)
+		if len(chunk) == chunkSize {
+			if err := upload.save(ctx, chunk); err != nil {
+				return nil, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if err := upload.save(ctx, chunk); err != nil {
+		return nil, err
+	}
+
+	// Rows of unknown accounts are reported chunk by chunk, after the rows the
+	// reader rejected; keep the report in file order, grouped by file for
+	// archives
+	sort.SliceStable(report.RowErrors, func(i, j int) bool {
+		a, b := report.RowErrors[i], report.RowErrors[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	log.Printf("Read %d transactions from %s", valid-upload.unknown, source)
+	result := &ProcessResult{
+		RowsRead:     valid - upload.unknown + report.InvalidRows(),
+		RowsRejected: report.InvalidRows(),
+	}
+	if report.HasErrors() {
+		if err := uc.handleInvalidRows(ctx, report); err != nil {
+			return result, err
+		}
+	}
+
+	result.RowsRejected += len(upload.conflicts)
+	if len(upload.conflicts) > 0 && uc.Policy == PolicyRejectFile {
+		return result, fmt.Errorf("file rejected: %d transactions conflict with stored ones, first conflict: %s", len(upload.conflicts), upload.conflicts[0])
+	}
+
+	duplicates := valid - upload.unknown - upload.inserted - len(upload.conflicts)
+	log.Printf("%d new transactions, %d already processed, %d conflicting", upload.inserted, duplicates, len(upload.conflicts))
+	result.RowsDuplicate = duplicates
+
+	if upload.inserted > 0 {
+		// Enqueue the summary emails the new transactions owe with the same
+		// commit, so the upload is stored completely or not at all
+		now := time.Now()
+		outbox := make([]entities.OutboxEntry, 0, len(upload.accounts))
+		for _, accountId := range upload.accounts {
+			outbox = append(outbox, entities.OutboxEntry{
+				UploadID:      uploadID,
+				AccountID:     accountId,
+				Status:        entities.OutboxPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+		if err := writer.Commit(ctx, outbox); err != nil {
+			return nil, fmt.Errorf("could not save transactions: %w", err)
+		}
+	}
+	result.RowsInserted = upload.inserted
+
+	// The reports are published once the valid rows are stored, so a failed
+	// upload never leaves a quarantine file behind for rows it did not skip
+	if report.HasErrors() {
+		uc.publishReports(ctx, report)
 	}
+
+	return result, nil
+}
+
+// uploadChunks saves the chunks of one upload and remembers the accounts that
+// received new transactions.
+type uploadChunks struct {
+	repo      interfaces.TransactionRepository
+	writer    interfaces.UploadWriter
+	report    *entities.ValidationReport
+	uploadID  string
+	checkAll  bool            // Stop storing once the report has errors, the file is rejected
+	known     map[string]bool // Accounts already looked up, and whether they exist
+	seen      map[string]bool // Accounts already in accounts
+	accounts  []string        // Accounts with new transactions, in order of appearance
+	inserted  int
+	unknown   int      // Rows of unregistered accounts, added to the report
+	conflicts []string // Descriptions of the transactions whose ID is stored with other values
 }
 
-// Execute reads the CSV file, processes each transaction, and saves them to the database.
-func (uc *ProcessTransactions) Execute(reader *csv.Reader) (map[string][]entities.Transaction, error) {
-	// Read the transactions from the file
-	transactions, err := uc.FileReader.ReadTransactions(reader)
+// save stores the transactions of a chunk that are not stored yet.
+func (u *uploadChunks) save(ctx context.Context, chunk []entities.Transaction) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	// Rows of unknown accounts would fail the foreign key and roll back the
+	// whole upload, so they are handled like any other invalid row
+	chunk, err := u.checkAccounts(ctx, chunk)
 	if err != nil {
-		log.Printf("Could not read transactions: %v", err)
-		return nil, fmt.Errorf("could not read transactions: %v", err)
+		return err
 	}
 
-	log.Printf("Read %d transactions from CSV file", len(transactions))
+	// A rejected file stores nothing, but is read to the end so the report
+	// lists every invalid row
+	if u.checkAll && u.report.HasErrors() {
+		return nil
+	}
 
-	var filteredTransaction []entities.Transaction
+	// Look up which transactions are already stored, by an earlier upload or
+	// an earlier chunk, in a single batch
+	ids := make([]string, len(chunk))
+	for i, transaction := range chunk {
+		ids[i] = transaction.ID
+	}
+	existing, err := u.writer.GetExistingTransactions(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("could not check for processed transactions: %w", err)
+	}
 
-	for _, transaction := range transactions {
-		t11n, _ := uc.TransactionRepo.GetTransaction(transaction.ID)
-		if t11n != nil {
+	var filtered []entities.Transaction
+	for _, transaction := range chunk {
+		if stored, exists := existing[transaction.ID]; exists {
+			if !sameTransaction(stored, transaction) {
+				conflict := fmt.Sprintf("transaction %s of account %s is stored as %s on %s, the file has %s on %s",
+					transaction.ID, transaction.AccountID, stored.Amount, stored.TransactionDate.Format("2006-01-02"),
+					transaction.Amount, transaction.TransactionDate.Format("2006-01-02"))
+				log.Printf("Conflicting transaction: %s", conflict)
+				u.conflicts = append(u.conflicts, conflict)
+			}
 			continue
 		}
-		filteredTransaction = append(filteredTransaction, transaction)
+		existing[transaction.ID] = transaction // Repeated within the chunk
+		transaction.UploadID = u.uploadID
+		filtered = append(filtered, transaction)
+		if !u.seen[transaction.AccountID] {
+			u.seen[transaction.AccountID] = true
+			u.accounts = append(u.accounts, transaction.AccountID)
+		}
 	}
 
-	// Process each transaction and save to the database
-	for _, txn := range filteredTransaction {
+	if len(filtered) > 0 {
+		if err := u.writer.SaveTransactions(ctx, filtered); err != nil {
+			return fmt.Errorf("could not save transactions: %w", err)
+		}
+	}
+	u.inserted += len(filtered)
+	return nil
+}
 
-		// Save the transaction to the database
-		err = uc.TransactionRepo.SaveTransaction(txn)
+// checkAccounts returns the transactions of registered accounts and adds the
+// others to the report as invalid rows. Each account is looked up once per
+// upload.
+func (u *uploadChunks) checkAccounts(ctx context.Context, chunk []entities.Transaction) ([]entities.Transaction, error) {
+	var accountIds []string
+	for _, transaction := range chunk {
+		if _, checked := u.known[transaction.AccountID]; !checked {
+			u.known[transaction.AccountID] = false
+			accountIds = append(accountIds, transaction.AccountID)
+		}
+	}
+	if len(accountIds) > 0 {
+		known, err := u.repo.GetExistingAccountIDs(ctx, accountIds)
 		if err != nil {
-			return nil, fmt.Errorf("could not save transaction: %v", err)
+			return nil, fmt.Errorf("could not check the accounts: %w", err)
+		}
+		for _, accountId := range accountIds {
+			u.known[accountId] = known[accountId]
 		}
 	}
 
-	accountsToTransaction := make(map[string][]entities.Transaction)
+	valid := chunk[:0]
+	for _, transaction := range chunk {
+		if u.known[transaction.AccountID] {
+			valid = append(valid, transaction)
+			continue
+		}
+		u.unknown++
+		u.report.Add(entities.RowError{
+			File:   transaction.File,
+			Line:   transaction.Line,
+			Column: "AccountId",
+			Value:  transaction.AccountID,
+			Reason: "unknown account",
+			Record: transaction.Record,
+		})
+	}
+	return valid, nil
+}
+
+// handleInvalidRows logs the invalid rows and applies the validation policy.
+// It returns an error when the whole file must be rejected, after publishing
+// the error report of the rejected file.
+func (uc *ProcessTransactions) handleInvalidRows(ctx context.Context, report *entities.ValidationReport) error {
+	for _, rowError := range report.RowErrors {
+		log.Printf("Invalid row in %s: %v", report.Source, rowError)
+	}
+
+	switch uc.Policy {
+	case PolicySkipBadRows:
+		log.Printf("Skipping %d invalid rows", report.InvalidRows())
+	case PolicyQuarantine:
+		// Rows left out of the report could not be quarantined, and
+		// skipping them would lose them
+		if report.OmittedRows > 0 {
+			uc.reportErrors(ctx, report)
+			return fmt.Errorf("file rejected: %d invalid rows, more than can be quarantined", report.InvalidRows())
+		}
+		log.Printf("Quarantining %d invalid rows", report.InvalidRows())
+	default:
+		uc.reportErrors(ctx, report)
+		return fmt.Errorf("file rejected: %d invalid rows, first error: %w", report.InvalidRows(), report.RowErrors[0])
+	}
 
-	for _, transaction := range filteredTransaction {
-		accountsToTransaction[transaction.AccountID] = append(accountsToTransaction[transaction.AccountID], transaction)
+	return nil
+}
+
+// publishReports publishes the error report of a processed file and, under
+// the quarantine policy, its invalid rows.
+func (uc *ProcessTransactions) publishReports(ctx context.Context, report *entities.ValidationReport) {
+	uc.reportErrors(ctx, report)
+	if uc.Policy != PolicyQuarantine {
+		return
 	}
+	for _, reporter := range uc.ErrorReporters {
+		if err := reporter.Quarantine(ctx, report); err != nil {
+			log.Printf("Could not quarantine invalid rows: %v", err)
+		}
+	}
+}
+
+// reportErrors publishes the error report of a file. Reporting failures are
+// logged so they never block the upload itself.
+func (uc *ProcessTransactions) reportErrors(ctx context.Context, report *entities.ValidationReport) {
+	for _, reporter := range uc.ErrorReporters {
+		if err := reporter.ReportErrors(ctx, report); err != nil {
+			log.Printf("Could not report invalid rows: %v", err)
+		}
+	}
+}
 
-	return accountsToTransaction, nil
+// sameTransaction reports whether two transactions with the same ID hold the
+// same values, so one is a re-upload of the other rather than a conflict.
+func sameTransaction(a, b entities.Transaction) bool {
+	return a.AccountID == b.AccountID &&
+		a.Amount == b.Amount &&
+		a.TransactionDate.Equal(b.TransactionDate) &&
+		a.Description == b.Description &&
+		a.Merchant == b.Merchant
 }
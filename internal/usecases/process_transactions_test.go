@@ -0,0 +1,415 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/memory"
+	"transactions-summary/internal/interfaces"
+)
+
+// fakeReader returns fixed transactions and invalid rows for any file, followed
+// by err when it is set.
+type fakeReader struct {
+	transactions []entities.Transaction
+	rowErrors    []entities.RowError
+	omittedRows  int
+	err          error
+}
+
+func (r *fakeReader) ReadTransactions(ctx context.Context, file entities.FileInfo, input io.Reader) (iter.Seq2[entities.Transaction, error], *entities.ValidationReport) {
+	report := &entities.ValidationReport{Source: file.Source, RowErrors: r.rowErrors, OmittedRows: r.omittedRows}
+	return func(yield func(entities.Transaction, error) bool) {
+		for _, transaction := range r.transactions {
+			if !yield(transaction, nil) {
+				return
+			}
+		}
+		if r.err != nil {
+			yield(entities.Transaction{}, r.err)
+		}
+	}, report
+}
+
+// fakeReporter records the reports it publishes.
+type fakeReporter struct {
+	reported    int
+	quarantined int
+	last        *entities.ValidationReport
+}
+
+func (r *fakeReporter) ReportErrors(ctx context.Context, report *entities.ValidationReport) error {
+	r.reported++
+	r.last = report
+	return nil
+}
+
+func (r *fakeReporter) Quarantine(ctx context.Context, report *entities.ValidationReport) error {
+	r.quarantined++
+	return nil
+}
+
+// failingRepo fails every commit.
+type failingRepo struct {
+	*memory.InMemoryTransactionRepo
+}
+
+func (r failingRepo) BeginUpload(ctx context.Context) (interfaces.UploadWriter, error) {
+	writer, err := r.InMemoryTransactionRepo.BeginUpload(ctx)
+	return failingWriter{writer}, err
+}
+
+// failingWriter fails to commit.
+type failingWriter struct {
+	interfaces.UploadWriter
+}
+
+func (w failingWriter) Commit(ctx context.Context, outbox []entities.OutboxEntry) error {
+	return errors.New("database is down")
+}
+
+func TestProcessTransactionsExecute(t *testing.T) {
+	stored := testTransaction("stored", "1", "2024-07-01", "5")
+
+	tests := []struct {
+		name         string
+		transactions []entities.Transaction
+		readErr      error
+		want         map[string][]string // Transaction IDs by account
+		wantErr      bool
+	}{
+		{
+			name: "new transactions are saved and grouped by account",
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("b", "2", "2024-07-28", "-10.25"),
+				testTransaction("c", "1", "2024-08-02", "-20.5"),
+			},
+			want: map[string][]string{"1": {"a", "c"}, "2": {"b"}},
+		},
+		{
+			name: "stored transactions are skipped",
+			transactions: []entities.Transaction{
+				stored,
+				testTransaction("a", "2", "2024-07-15", "60.5"),
+			},
+			want: map[string][]string{"2": {"a"}},
+		},
+		{
+			name: "transactions repeated within the file are saved once",
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+			},
+			want: map[string][]string{"1": {"a"}},
+		},
+		{
+			name: "a transaction stored with other values rejects the file",
+			transactions: []entities.Transaction{
+				testTransaction("a", "2", "2024-07-15", "60.5"),
+				testTransaction("stored", "1", "2024-07-01", "6"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "a transaction repeated with other values rejects the file",
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("a", "1", "2024-07-16", "60.5"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "read errors stop the upload",
+			readErr: errors.New("invalid transaction amount"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo()
+			if err := repo.SaveTransaction(context.Background(), stored); err != nil {
+				t.Fatal(err)
+			}
+			uc := NewProcessTransactions(repo, &fakeReader{transactions: tt.transactions, err: tt.readErr})
+
+			result, err := uc.Execute(context.Background(), entities.FileInfo{Source: "test"}, strings.NewReader(""))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Execute() returned no error")
+				}
+				if _, err := repo.GetTransaction(context.Background(), "a"); err == nil {
+					t.Error("a rejected file saved transaction a")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Every new transaction is stored and owes its account a summary
+			inserted := 0
+			for account, ids := range tt.want {
+				stored, err := repo.ListTransactionsByUpload(context.Background(), account, "test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, transaction := range stored {
+					got = append(got, transaction.ID)
+				}
+				if !slices.Equal(got, ids) {
+					t.Errorf("account %s stored %v, want %v", account, got, ids)
+				}
+				inserted += len(ids)
+			}
+			if result.RowsInserted != inserted {
+				t.Errorf("RowsInserted = %d, want %d", result.RowsInserted, inserted)
+			}
+			outbox, err := repo.ListOutbox(context.Background(), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(outbox) != len(tt.want) {
+				t.Errorf("got %d outbox entries, want %d", len(outbox), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestProcessTransactionsValidationPolicy(t *testing.T) {
+	valid := testTransaction("a", "1", "2024-07-15", "60.5")
+	invalid := entities.RowError{Line: 3, Column: "Amount", Value: "abc", Reason: "invalid transaction amount"}
+	conflicting := testTransaction("stored", "1", "2024-07-01", "6")
+	unknownAccount := testTransaction("u", "9", "2024-07-02", "7")
+	unknownAccount.Line = 4
+
+	tests := []struct {
+		name            string
+		policy          ValidationPolicy
+		transactions    []entities.Transaction
+		rowErrors       []entities.RowError
+		omittedRows     int
+		saveFails       bool
+		wantErr         bool
+		wantSaved       bool
+		wantReported    int
+		wantQuarantined int
+		wantRowErrors   []string // Columns of the reported row errors
+	}{
+		{
+			name:         "reject-file saves nothing and reports the rows",
+			policy:       PolicyRejectFile,
+			transactions: []entities.Transaction{valid},
+			rowErrors:    []entities.RowError{invalid},
+			wantErr:      true,
+			wantReported: 1,
+		},
+		{
+			name:         "skip-bad-rows saves the valid rows and reports the others",
+			policy:       PolicySkipBadRows,
+			transactions: []entities.Transaction{valid},
+			rowErrors:    []entities.RowError{invalid},
+			wantSaved:    true,
+			wantReported: 1,
+		},
+		{
+			name:            "quarantine saves the valid rows and quarantines the others",
+			policy:          PolicyQuarantine,
+			transactions:    []entities.Transaction{valid},
+			rowErrors:       []entities.RowError{invalid},
+			wantSaved:       true,
+			wantReported:    1,
+			wantQuarantined: 1,
+		},
+		{
+			name:         "quarantine rejects a file with more invalid rows than the report keeps",
+			policy:       PolicyQuarantine,
+			transactions: []entities.Transaction{valid},
+			rowErrors:    []entities.RowError{invalid},
+			omittedRows:  1,
+			wantErr:      true,
+			wantReported: 1,
+		},
+		{
+			name:         "a failed save publishes no reports",
+			policy:       PolicyQuarantine,
+			transactions: []entities.Transaction{valid},
+			rowErrors:    []entities.RowError{invalid},
+			saveFails:    true,
+			wantErr:      true,
+		},
+		{
+			name:          "unknown accounts reject the file under reject-file",
+			policy:        PolicyRejectFile,
+			transactions:  []entities.Transaction{valid, unknownAccount},
+			rowErrors:     []entities.RowError{invalid},
+			wantErr:       true,
+			wantReported:  1,
+			wantRowErrors: []string{"Amount", "AccountId"},
+		},
+		{
+			name:            "unknown accounts are quarantined with the invalid rows",
+			policy:          PolicyQuarantine,
+			transactions:    []entities.Transaction{unknownAccount, valid},
+			wantSaved:       true,
+			wantReported:    1,
+			wantQuarantined: 1,
+			wantRowErrors:   []string{"AccountId"},
+		},
+		{
+			name:         "conflicts reject the file under reject-file",
+			policy:       PolicyRejectFile,
+			transactions: []entities.Transaction{valid, conflicting},
+			wantErr:      true,
+		},
+		{
+			name:         "conflicts are skipped under skip-bad-rows",
+			policy:       PolicySkipBadRows,
+			transactions: []entities.Transaction{valid, conflicting},
+			wantSaved:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryRepo := newTestRepo()
+			if err := memoryRepo.SaveTransaction(context.Background(), testTransaction("stored", "1", "2024-07-01", "5")); err != nil {
+				t.Fatal(err)
+			}
+			var repo interfaces.TransactionRepository = memoryRepo
+			if tt.saveFails {
+				repo = failingRepo{memoryRepo}
+			}
+			reporter := &fakeReporter{}
+			uc := NewProcessTransactions(repo, &fakeReader{transactions: tt.transactions, rowErrors: tt.rowErrors, omittedRows: tt.omittedRows})
+			uc.Policy = tt.policy
+			uc.ErrorReporters = []interfaces.ErrorReporter{reporter}
+
+			_, err := uc.Execute(context.Background(), entities.FileInfo{Source: "test"}, strings.NewReader(""))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, want error %v", err, tt.wantErr)
+			}
+			if _, err := memoryRepo.GetTransaction(context.Background(), valid.ID); (err == nil) != tt.wantSaved {
+				t.Errorf("transaction saved = %v, want %v", err == nil, tt.wantSaved)
+			}
+			if reporter.reported != tt.wantReported || reporter.quarantined != tt.wantQuarantined {
+				t.Errorf("reported %d and quarantined %d times, want %d and %d", reporter.reported, reporter.quarantined, tt.wantReported, tt.wantQuarantined)
+			}
+			if tt.wantRowErrors != nil {
+				var columns []string
+				for _, rowError := range reporter.last.RowErrors {
+					columns = append(columns, rowError.Column)
+				}
+				if !slices.Equal(columns, tt.wantRowErrors) {
+					t.Errorf("reported columns %v, want %v", columns, tt.wantRowErrors)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessTransactionsChunks(t *testing.T) {
+	unknownAccount := testTransaction("u", "9", "2024-07-20", "7")
+	unknownAccount.Line = 6
+
+	tests := []struct {
+		name          string
+		policy        ValidationPolicy
+		transactions  []entities.Transaction
+		wantErr       bool
+		wantRows      [4]int // Read, duplicate, inserted and rejected
+		wantStored    []string
+		wantRowErrors []int // Lines of the reported row errors
+	}{
+		{
+			name:   "chunks are committed together",
+			policy: PolicyRejectFile,
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("b", "2", "2024-07-16", "-10.25"),
+				testTransaction("c", "1", "2024-07-17", "-20.5"),
+				testTransaction("a", "1", "2024-07-15", "60.5"), // Repeated from the first chunk
+				testTransaction("d", "2", "2024-07-18", "3"),
+			},
+			wantRows:   [4]int{5, 1, 4, 0},
+			wantStored: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:   "an unknown account in a later chunk rejects the whole file",
+			policy: PolicyRejectFile,
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("b", "2", "2024-07-16", "-10.25"),
+				unknownAccount,
+			},
+			wantErr:       true,
+			wantRows:      [4]int{3, 0, 0, 1},
+			wantRowErrors: []int{6},
+		},
+		{
+			name:   "an unknown account in a later chunk is skipped",
+			policy: PolicySkipBadRows,
+			transactions: []entities.Transaction{
+				testTransaction("a", "1", "2024-07-15", "60.5"),
+				testTransaction("b", "2", "2024-07-16", "-10.25"),
+				unknownAccount,
+				testTransaction("c", "1", "2024-07-17", "-20.5"),
+			},
+			wantRows:      [4]int{4, 0, 3, 1},
+			wantStored:    []string{"a", "b", "c"},
+			wantRowErrors: []int{6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo()
+			reporter := &fakeReporter{}
+			uc := NewProcessTransactions(repo, &fakeReader{transactions: tt.transactions})
+			uc.Policy = tt.policy
+			uc.ChunkSize = 2
+			uc.ErrorReporters = []interfaces.ErrorReporter{reporter}
+
+			result, err := uc.Execute(context.Background(), entities.FileInfo{Source: "test"}, strings.NewReader(""))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, want error %v", err, tt.wantErr)
+			}
+			if rows := [4]int{result.RowsRead, result.RowsDuplicate, result.RowsInserted, result.RowsRejected}; rows != tt.wantRows {
+				t.Errorf("rows = %v, want %v", rows, tt.wantRows)
+			}
+
+			var stored []string
+			for _, account := range []string{"1", "2"} {
+				transactions, err := repo.ListTransactionsByUpload(context.Background(), account, "test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, transaction := range transactions {
+					stored = append(stored, transaction.ID)
+				}
+			}
+			slices.Sort(stored)
+			if !slices.Equal(stored, tt.wantStored) {
+				t.Errorf("stored %v, want %v", stored, tt.wantStored)
+			}
+
+			var lines []int
+			if reporter.last != nil {
+				for _, rowError := range reporter.last.RowErrors {
+					lines = append(lines, rowError.Line)
+				}
+			}
+			if !slices.Equal(lines, tt.wantRowErrors) {
+				t.Errorf("reported lines %v, want %v", lines, tt.wantRowErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/interfaces"
+)
+
+// BalanceDrift describes an account whose stored balances differ from the
+// balances recomputed from its transactions.
+type BalanceDrift struct {
+	AccountID      string
+	StoredDebit    entities.Money
+	StoredCredit   entities.Money
+	ComputedDebit  entities.Money
+	ComputedCredit entities.Money
+}
+
+// ReconcileBalances recomputes account balances from the stored transactions.
+type ReconcileBalances struct {
+	TransactionRepo interfaces.TransactionRepository
+}
+
+// NewReconcileBalances creates a new ReconcileBalances use case.
+func NewReconcileBalances(repo interfaces.TransactionRepository) *ReconcileBalances {
+	return &ReconcileBalances{
+		TransactionRepo: repo,
+	}
+}
+
+// Execute compares every account balance with the totals of its transactions
+// in the account currency and returns the accounts that drifted. When fix is
+// true every account is recomputed and overwritten atomically by the
+// repository, so uploads committed during the run are not lost.
+func (uc *ReconcileBalances) Execute(ctx context.Context, fix bool) ([]BalanceDrift, error) {
+	accounts, err := uc.TransactionRepo.GetAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve accounts: %w", err)
+	}
+
+	var drifts []BalanceDrift
+	for _, account := range accounts {
+		stored := &account
+		var debit, credit entities.Money
+		if fix {
+			var recomputed *entities.Account
+			stored, recomputed, err = uc.TransactionRepo.RecomputeBalances(ctx, account.ID)
+			if err != nil {
+				return nil, fmt.Errorf("could not fix account %s: %w", account.ID, err)
+			}
+			debit, credit = recomputed.DebitBalance, recomputed.CreditBalance
+		} else {
+			debit, credit, err = uc.TransactionRepo.GetTransactionTotals(ctx, account.ID, account.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("could not total transactions of account %s: %w", account.ID, err)
+			}
+		}
+
+		if debit == stored.DebitBalance && credit == stored.CreditBalance {
+			continue
+		}
+
+		drift := BalanceDrift{
+			AccountID:      account.ID,
+			StoredDebit:    stored.DebitBalance,
+			StoredCredit:   stored.CreditBalance,
+			ComputedDebit:  debit,
+			ComputedCredit: credit,
+		}
+		drifts = append(drifts, drift)
+		log.Printf("Account %s drifted: stored debit %s credit %s, computed debit %s credit %s",
+			account.ID, drift.StoredDebit, drift.StoredCredit, drift.ComputedDebit, drift.ComputedCredit)
+	}
+
+	return drifts, nil
+}
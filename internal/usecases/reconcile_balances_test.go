@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"transactions-summary/internal/entities"
+)
+
+func TestReconcileBalancesExecute(t *testing.T) {
+	repo := newTestRepo()
+	err := saveUpload(repo, []entities.Transaction{
+		testTransaction("a", "1", "2024-07-15", "60.5"),
+		testTransaction("b", "1", "2024-07-28", "-10.25"),
+		withCurrency(testTransaction("c", "1", "2024-07-30", "99"), "MXN"),
+		testTransaction("d", "2", "2024-07-30", "5"),
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Account 2 drifts from its transactions
+	if err := repo.UpdateAccount(context.Background(), &entities.Account{ID: "2", DebitBalance: usd("-1"), CreditBalance: usd("7")}); err != nil {
+		t.Fatal(err)
+	}
+	wantDrifts := []BalanceDrift{{
+		AccountID:      "2",
+		StoredDebit:    usd("-1"),
+		StoredCredit:   usd("7"),
+		ComputedDebit:  usd("0"),
+		ComputedCredit: usd("5"),
+	}}
+
+	reconcileBalances := NewReconcileBalances(repo)
+	tests := []struct {
+		name       string
+		fix        bool
+		wantDrifts []BalanceDrift
+	}{
+		{name: "report only", wantDrifts: wantDrifts},
+		{name: "report leaves the balances", wantDrifts: wantDrifts},
+		{name: "fix", fix: true, wantDrifts: wantDrifts},
+		{name: "fixed balances match", wantDrifts: nil},
+	}
+
+	for _, tt := range tests {
+		drifts, err := reconcileBalances.Execute(context.Background(), tt.fix)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(drifts, tt.wantDrifts) {
+			t.Errorf("%s: drifts = %+v, want %+v", tt.name, drifts, tt.wantDrifts)
+		}
+	}
+
+	// Transactions in another currency stay out of the balances
+	account, err := repo.GetAccount(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.DebitBalance != usd("-10.25") || account.CreditBalance != usd("60.5") {
+		t.Errorf("account 1 balances = %s / %s, want -10.25 / 60.50", account.DebitBalance, account.CreditBalance)
+	}
+}
@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"transactions-summary/internal/interfaces"
+)
+
+// ResendSummaries is a use case that puts summary emails back in the outbox
+// so the next dispatch delivers them again.
+type ResendSummaries struct {
+	OutboxRepo interfaces.OutboxRepository
+}
+
+// NewResendSummaries creates a new ResendSummaries use case.
+func NewResendSummaries(repo interfaces.OutboxRepository) *ResendSummaries {
+	return &ResendSummaries{
+		OutboxRepo: repo,
+	}
+}
+
+// Execute requeues the summaries of an account, of an upload, or of an account
+// in one upload, whether they were sent or failed. At least one of accountId
+// and uploadID is required. It returns the number of summaries requeued.
+func (uc *ResendSummaries) Execute(ctx context.Context, accountId string, uploadID string) (int, error) {
+	if accountId == "" && uploadID == "" {
+		return 0, fmt.Errorf("an account or an upload is required")
+	}
+
+	count, err := uc.OutboxRepo.RequeueOutbox(ctx, accountId, uploadID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("could not requeue summaries: %w", err)
+	}
+	return count, nil
+}
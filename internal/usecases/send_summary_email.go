@@ -1,129 +1,213 @@
 package usecases
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
-	"strings"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	"transactions-summary/internal/entities"
 	"transactions-summary/internal/interfaces"
 )
 
+// defaultSummaryWorkers is the number of accounts processed at the same time
+// when SendSummaryEmail.Workers is not set.
+const defaultSummaryWorkers = 4
+
+// ErrSummaryNotStarted is the cause of a summary that was never started
+// because ctx was canceled first. It wraps the context error.
+var ErrSummaryNotStarted = errors.New("summary not started")
+
+// SummaryStatus is the outcome of the summary email of one account.
+type SummaryStatus string
+
+const (
+	// SummarySent means the summary email was sent.
+	SummarySent SummaryStatus = "sent"
+	// SummarySkippedUnknownAccount means the account does not exist, so there
+	// was nobody to send the summary to.
+	SummarySkippedUnknownAccount SummaryStatus = "skipped-unknown-account"
+	// SummaryFailed means the summary could not be generated, rendered or sent.
+	SummaryFailed SummaryStatus = "failed"
+)
+
+// AccountOutcome records what happened to the summary of one account.
+type AccountOutcome struct {
+	AccountID string
+	Email     string // Recipient, when the account was found
+	Status    SummaryStatus
+	Err       error // Cause of a skipped or failed summary
+}
+
+// SummaryReport holds the outcome of every account.
+type SummaryReport struct {
+	Outcomes []AccountOutcome
+}
+
+// Count returns the number of accounts with the given status.
+func (r *SummaryReport) Count(status SummaryStatus) int {
+	count := 0
+	for _, outcome := range r.Outcomes {
+		if outcome.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
 // SendSummaryEmail is a use case that generates a summary and sends it via email.
+// Each statement generator adds one attachment, e.g. a CSV or PDF statement.
 type SendSummaryEmail struct {
 	GenerateSummaryUseCase *GenerateSummary
 	EmailSender            interfaces.EmailSender
+	Renderer               interfaces.SummaryRenderer
+	Statements             []interfaces.StatementGenerator
+	ReplyTo                string   // Optional Reply-To address
+	Bcc                    []string // Optional blind copies of every summary
+	Workers                int      // Accounts processed at the same time
 }
 
 // NewSendSummaryEmail creates a new SendSummaryEmail use case.
-func NewSendSummaryEmail(generateSummary *GenerateSummary, emailSender interfaces.EmailSender) *SendSummaryEmail {
+func NewSendSummaryEmail(generateSummary *GenerateSummary, emailSender interfaces.EmailSender, renderer interfaces.SummaryRenderer) *SendSummaryEmail {
 	return &SendSummaryEmail{
 		GenerateSummaryUseCase: generateSummary,
 		EmailSender:            emailSender,
+		Renderer:               renderer,
+		Workers:                defaultSummaryWorkers,
 	}
 }
 
-// Execute generates the summary and sends it to the specified email address.
-func (uc *SendSummaryEmail) Execute(accountToTransactions map[string][]entities.Transaction) error {
-	// Generate the summary
-	for account, transactions := range accountToTransactions {
-		summaryResult, toEmail, err := uc.GenerateSummaryUseCase.Execute(account, transactions)
+// SummaryJob is one summary email to send: an account and the transactions it covers.
+type SummaryJob struct {
+	AccountID    string
+	Transactions []entities.Transaction
+}
 
-		if err != nil {
-			log.Printf("Could not generate summary for account %s: %v", account, err)
-			return fmt.Errorf("could not generate summary: %v", err)
-		}
+// Execute generates and sends the summary of every account, ordered by account ID.
+func (uc *SendSummaryEmail) Execute(ctx context.Context, accountToTransactions map[string][]entities.Transaction) (*SummaryReport, error) {
+	accounts := make([]string, 0, len(accountToTransactions))
+	for account := range accountToTransactions {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	jobs := make([]SummaryJob, len(accounts))
+	for i, account := range accounts {
+		jobs[i] = SummaryJob{AccountID: account, Transactions: accountToTransactions[account]}
+	}
+	return uc.SendAll(ctx, jobs)
+}
 
-		// Format the summary into HTML
-		emailBody := uc.formatSummaryAsHTML(summaryResult)
+// SendAll sends the summaries of the jobs with a pool of workers. A failing
+// account does not stop the others: each job gets an outcome in the report, at
+// the same index. When ctx is canceled the jobs not started yet are reported
+// as failed with ErrSummaryNotStarted and the context error, which is also
+// returned.
+func (uc *SendSummaryEmail) SendAll(ctx context.Context, summaryJobs []SummaryJob) (*SummaryReport, error) {
+	workers := uc.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	outcomes := make([]AccountOutcome, len(summaryJobs))
+	jobs := make(chan int)
+	var notStarted atomic.Int32
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// A job handed out as ctx is canceled is not started
+				if err := ctx.Err(); err != nil {
+					outcomes[i] = AccountOutcome{AccountID: summaryJobs[i].AccountID, Status: SummaryFailed, Err: fmt.Errorf("%w: %w", ErrSummaryNotStarted, err)}
+					notStarted.Add(1)
+					continue
+				}
+				outcomes[i] = uc.sendSummary(ctx, summaryJobs[i].AccountID, summaryJobs[i].Transactions)
+			}
+		}()
+	}
 
-		// Send the email
-		subject := "Monthly Transactions Summary"
-		if err := uc.EmailSender.SendEmail(toEmail, subject, emailBody); err != nil {
-			log.Printf("Could not send summary email to %s: %v", toEmail, err)
-			return fmt.Errorf("could not send summary email: %v", err)
+	// Hand out accounts until they run out or ctx is canceled
+	next := 0
+dispatch:
+	for ; next < len(summaryJobs); next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break dispatch
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < len(summaryJobs); i++ {
+		outcomes[i] = AccountOutcome{AccountID: summaryJobs[i].AccountID, Status: SummaryFailed, Err: fmt.Errorf("%w: %w", ErrSummaryNotStarted, ctx.Err())}
+	}
 
-	return nil
+	report := &SummaryReport{Outcomes: outcomes}
+	log.Printf("Summaries: %d sent, %d skipped, %d failed",
+		report.Count(SummarySent), report.Count(SummarySkippedUnknownAccount), report.Count(SummaryFailed))
+	if next < len(summaryJobs) || notStarted.Load() > 0 {
+		return report, fmt.Errorf("summaries canceled: %w", ctx.Err())
+	}
+	return report, nil
 }
 
-// formatSummaryAsHTML converts the summary result to an HTML format.
-func (uc *SendSummaryEmail) formatSummaryAsHTML(summary *entities.SummaryResult) string {
-	var sb strings.Builder
-
-	// Begin email container
-	sb.WriteString(`
-    <div style="background-color: #ffffff; max-width: 600px; margin: 0 auto; font-family: Arial, sans-serif;">
-        <!-- Header with logo -->
-        <div style="background-color: #b9ff66; text-align: center; padding: 20px;">
-            <img src="https://upload.wikimedia.org/wikipedia/commons/thumb/b/b0/Stori_Logo_2023.svg/512px-Stori_Logo_2023.svg.png" 
-                 alt="Stori Logo" 
-                 style="width: 150px; height: auto;">
-        </div>
-
-        <!-- Main Content -->
-        <div style="padding: 30px 40px;">
-            <h1 style="color: #000000; font-size: 24px; margin-bottom: 20px;">Transactions Summary</h1>
-            
-            <!-- Total Summary Cards -->
-            <div style="display: inline-block; width: 45%; margin-right: 5%; background-color: #f8f9fa; padding: 15px; border-radius: 8px;">
-                <h3 style="margin: 0; color: #666;">Total Credit</h3>
-                <p style="font-size: 24px; margin: 10px 0; color: #28a745;">`)
-	sb.WriteString(strconv.FormatFloat(summary.TotalCredit, 'f', 2, 64))
-	sb.WriteString(`</p>
-            </div>
-            <div style="display: inline-block; width: 45%; background-color: #f8f9fa; padding: 15px; border-radius: 8px;">
-                <h3 style="margin: 0; color: #666;">Total Debit</h3>
-                <p style="font-size: 24px; margin: 10px 0; color: #dc3545;">`)
-	sb.WriteString(strconv.FormatFloat(summary.TotalDebit, 'f', 2, 64))
-	sb.WriteString(`</div>
-
-            <!-- Monthly Breakdown -->
-            <h2 style="color: #000000; font-size: 20px; margin: 30px 0 20px;">Monthly Breakdown</h2>
-            <table style="width: 100%; border-collapse: collapse; margin-bottom: 30px;">
-                <thead>
-                    <tr style="background-color: #b9ff66;">
-                        <th style="padding: 12px; text-align: left; border-bottom: 2px solid #dee2e6;">Month</th>
-                        <th style="padding: 12px; text-align: right; border-bottom: 2px solid #dee2e6;">Transactions</th>
-                        <th style="padding: 12px; text-align: right; border-bottom: 2px solid #dee2e6;">Avg Credit</th>
-                        <th style="padding: 12px; text-align: right; border-bottom: 2px solid #dee2e6;">Avg Debit</th>
-                    </tr>
-                </thead>
-                <tbody>`)
-
-	// Add monthly rows
-	for _, monthSummary := range summary.MonthlySummaries {
-		sb.WriteString(fmt.Sprintf(`
-                    <tr style="border-bottom: 1px solid #dee2e6;">
-                        <td style="padding: 12px; text-align: left;">%s</td>
-                        <td style="padding: 12px; text-align: center;">%d</td>
-                        <td style="padding: 12px; text-align: right;">$%.2f</td>
-                        <td style="padding: 12px; text-align: right;">$%.2f</td>
-                    </tr>`,
-			monthSummary.Month,
-			monthSummary.NumTransactions,
-			monthSummary.AverageCredit,
-			monthSummary.AverageDebit))
+// sendSummary generates, renders and sends the summary of one account.
+func (uc *SendSummaryEmail) sendSummary(ctx context.Context, account string, transactions []entities.Transaction) AccountOutcome {
+	outcome := AccountOutcome{AccountID: account}
+
+	summaryResult, toEmail, err := uc.GenerateSummaryUseCase.Execute(ctx, account, transactions)
+	if err != nil {
+		outcome.Err = err
+		if errors.Is(err, entities.ErrAccountNotFound) {
+			log.Printf("Skipping summary of unknown account %s", account)
+			outcome.Status = SummarySkippedUnknownAccount
+			return outcome
+		}
+		log.Printf("Could not generate summary for account %s: %v", account, err)
+		outcome.Status = SummaryFailed
+		return outcome
+	}
+	outcome.Email = toEmail
+
+	// Render the summary into the email subject and bodies
+	message, err := uc.Renderer.RenderSummary(summaryResult)
+	if err != nil {
+		log.Printf("Could not render summary for account %s: %v", account, err)
+		return failed(outcome, fmt.Errorf("could not render summary: %w", err))
 	}
+	message.To = toEmail
+	message.ReplyTo = uc.ReplyTo
+	message.Bcc = uc.Bcc
+
+	// Attach the statements of the account
+	for _, statement := range uc.Statements {
+		attachment, err := statement.GenerateStatement(summaryResult)
+		if err != nil {
+			log.Printf("Could not generate statement for account %s: %v", account, err)
+			return failed(outcome, fmt.Errorf("could not generate statement: %w", err))
+		}
+		message.Attachments = append(message.Attachments, *attachment)
+	}
+
+	// Send the email
+	if err := uc.EmailSender.SendEmail(ctx, *message); err != nil {
+		log.Printf("Could not send summary email to %s: %v", toEmail, err)
+		return failed(outcome, fmt.Errorf("could not send summary email: %w", err))
+	}
+
+	outcome.Status = SummarySent
+	return outcome
+}
 
-	// Close the table and add footer
-	sb.WriteString(`
-                </tbody>
-            </table>
-        </div>
-
-        <!-- Footer -->
-        <div style="background-color: #f8f9fa; padding: 20px; text-align: center;">
-        
-            <p style="color: #666; font-size: 12px; margin: 0;">
-                © 2024 Stori. All rights reserved.<br>
-                <a href="#" style="color: #666; text-decoration: none;">Privacy Policy</a> | 
-                <a href="#" style="color: #666; text-decoration: none;">Unsubscribe</a>
-            </p>
-        </div>
-    </div>`)
-
-	return sb.String()
+func failed(outcome AccountOutcome, err error) AccountOutcome {
+	outcome.Status = SummaryFailed
+	outcome.Err = err
+	return outcome
 }
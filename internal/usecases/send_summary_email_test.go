@@ -0,0 +1,245 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"transactions-summary/internal/entities"
+	"transactions-summary/internal/infrastructure/memory"
+)
+
+// fakeRenderer renders a summary as a message with a fixed subject.
+type fakeRenderer struct{}
+
+func (fakeRenderer) RenderSummary(summary *entities.SummaryResult) (*entities.EmailMessage, error) {
+	return &entities.EmailMessage{Subject: "Your summary", TextBody: "summary"}, nil
+}
+
+// fakeEmailSender records the messages it sends. Messages to an address in
+// fail return its error, and onSend, when set, runs before each message.
+type fakeEmailSender struct {
+	mu     sync.Mutex
+	sent   []entities.EmailMessage
+	fail   map[string]error
+	onSend func()
+}
+
+func (s *fakeEmailSender) SendEmail(ctx context.Context, message entities.EmailMessage) error {
+	if s.onSend != nil {
+		s.onSend()
+	}
+	if err := s.fail[message.To]; err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+// sentTo returns the recipients of the sent messages.
+func (s *fakeEmailSender) sentTo() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recipients := make([]string, len(s.sent))
+	for i, message := range s.sent {
+		recipients[i] = message.To
+	}
+	return recipients
+}
+
+// idsStatement attaches the IDs of the summary transactions, one per line.
+type idsStatement struct{}
+
+func (idsStatement) GenerateStatement(summary *entities.SummaryResult) (*entities.Attachment, error) {
+	var ids []string
+	for _, transaction := range summary.Transactions {
+		ids = append(ids, transaction.ID)
+	}
+	slices.Sort(ids)
+	data := ""
+	for _, id := range ids {
+		data += id + "\n"
+	}
+	return &entities.Attachment{Filename: "ids.txt", Data: []byte(data)}, nil
+}
+
+func TestSendSummaryEmailStatements(t *testing.T) {
+	history := testTransaction("h1", "1", "2024-06-10", "100")
+	upload := []entities.Transaction{
+		testTransaction("u1", "1", "2024-07-20", "-8"),
+		testTransaction("u2", "1", "2024-08-01", "20"),
+	}
+
+	tests := []struct {
+		name string
+		mode SummaryMode
+		want string
+	}{
+		{name: "upload", mode: SummaryModeUpload, want: "u1\nu2\n"},
+		{name: "lifetime", mode: SummaryModeLifetime, want: "h1\nu1\nu2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo()
+			if err := saveUpload(repo, append([]entities.Transaction{history}, upload...), nil); err != nil {
+				t.Fatal(err)
+			}
+			generateSummary := NewGenerateSummary(repo)
+			generateSummary.Mode = tt.mode
+			sender := &fakeEmailSender{}
+			uc := NewSendSummaryEmail(generateSummary, sender, fakeRenderer{})
+			uc.Statements = append(uc.Statements, idsStatement{})
+			uc.ReplyTo = "support@example.com"
+
+			if _, err := uc.Execute(context.Background(), map[string][]entities.Transaction{"1": upload}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sender.sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sender.sent))
+			}
+			message := sender.sent[0]
+			if message.To != "one@example.com" || message.ReplyTo != "support@example.com" {
+				t.Errorf("message to %q replying to %q, want one@example.com and support@example.com", message.To, message.ReplyTo)
+			}
+			// The statement covers the same transactions as the summary
+			if len(message.Attachments) != 1 || string(message.Attachments[0].Data) != tt.want {
+				t.Errorf("attachments = %+v, want the IDs %q", message.Attachments, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendSummaryEmailOutcomes(t *testing.T) {
+	errRejected := errors.New("550 mailbox unavailable")
+	tests := []struct {
+		name     string
+		accounts []string
+		fail     map[string]error
+		want     []SummaryStatus
+		wantSent int
+	}{
+		{
+			name:     "every summary is sent",
+			accounts: []string{"1", "2"},
+			want:     []SummaryStatus{SummarySent, SummarySent},
+			wantSent: 2,
+		},
+		{
+			name:     "unknown accounts are skipped",
+			accounts: []string{"1", "unknown"},
+			want:     []SummaryStatus{SummarySent, SummarySkippedUnknownAccount},
+			wantSent: 1,
+		},
+		{
+			name:     "a failing account does not stop the others",
+			accounts: []string{"1", "2"},
+			fail:     map[string]error{"one@example.com": errRejected},
+			want:     []SummaryStatus{SummaryFailed, SummarySent},
+			wantSent: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeEmailSender{fail: tt.fail}
+			uc := NewSendSummaryEmail(NewGenerateSummary(newTestRepo()), sender, fakeRenderer{})
+
+			accountToTransactions := make(map[string][]entities.Transaction)
+			for _, account := range tt.accounts {
+				accountToTransactions[account] = nil
+			}
+			report, err := uc.Execute(context.Background(), accountToTransactions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(report.Outcomes) != len(tt.want) {
+				t.Fatalf("got %d outcomes, want %d", len(report.Outcomes), len(tt.want))
+			}
+			for i, want := range tt.want {
+				outcome := report.Outcomes[i]
+				if outcome.AccountID != tt.accounts[i] || outcome.Status != want {
+					t.Errorf("outcome %d = %s %s, want %s %s", i, outcome.AccountID, outcome.Status, tt.accounts[i], want)
+				}
+				if want == SummaryFailed && !errors.Is(outcome.Err, errRejected) {
+					t.Errorf("outcome %d error = %v, want it to wrap %v", i, outcome.Err, errRejected)
+				}
+			}
+			if got := len(sender.sentTo()); got != tt.wantSent {
+				t.Errorf("sent %d emails, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestSendSummaryEmailCanceled(t *testing.T) {
+	// Enough accounts that some are still waiting when the context is canceled
+	const accountCount = 50
+
+	tests := []struct {
+		name     string
+		cancelAt int // Message whose sending cancels the context, -1 to cancel before Execute
+		wantSent int
+	}{
+		{name: "canceled before sending", cancelAt: -1, wantSent: 0},
+		{name: "canceled while sending", cancelAt: 0, wantSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAt < 0 {
+				cancel()
+			}
+
+			repo := memory.NewInMemoryTransactionRepo()
+			accountToTransactions := make(map[string][]entities.Transaction)
+			for i := 0; i < accountCount; i++ {
+				id := fmt.Sprintf("%02d", i)
+				repo.SeedAccounts([]entities.Account{{ID: id, Email: id + "@example.com"}})
+				accountToTransactions[id] = nil
+			}
+
+			calls := 0
+			sender := &fakeEmailSender{onSend: func() {
+				// A single worker sends the messages one after another
+				if calls == tt.cancelAt {
+					cancel()
+				}
+				calls++
+			}}
+			uc := NewSendSummaryEmail(NewGenerateSummary(repo), sender, fakeRenderer{})
+			uc.Workers = 1
+
+			report, err := uc.Execute(ctx, accountToTransactions)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("got error %v, want context.Canceled", err)
+			}
+
+			if len(report.Outcomes) != accountCount {
+				t.Fatalf("got %d outcomes, want %d", len(report.Outcomes), accountCount)
+			}
+			for i, outcome := range report.Outcomes {
+				if i < tt.wantSent {
+					if outcome.Status != SummarySent {
+						t.Errorf("outcome %d = %s, want %s", i, outcome.Status, SummarySent)
+					}
+					continue
+				}
+				if outcome.Status != SummaryFailed || !errors.Is(outcome.Err, context.Canceled) {
+					t.Errorf("outcome %d = %s: %v, want %s: context canceled", i, outcome.Status, outcome.Err, SummaryFailed)
+				}
+			}
+			if got := len(sender.sentTo()); got != tt.wantSent {
+				t.Errorf("sent %d emails, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}